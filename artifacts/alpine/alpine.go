@@ -0,0 +1,221 @@
+package alpine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/tests"
+)
+
+const releasesURL = "https://alpinelinux.org/releases.json"
+
+// Releases is the subset of the Alpine release index which is required to find the
+// supported release branches and their latest point releases.
+type Releases struct {
+	ReleaseBranches []ReleaseBranch `json:"release_branches"`
+}
+
+type ReleaseBranch struct {
+	RelBranch string    `json:"rel_branch"`
+	EOLDate   string    `json:"eol_date"`
+	Releases  []Release `json:"releases"`
+}
+
+type Release struct {
+	Version string `json:"version"`
+	Date    string `json:"date"`
+}
+
+type alpine struct {
+	Version  string
+	Variant  string
+	getter   http.Getter
+	Arch     string
+	Revision string
+}
+
+type alpineGatherer struct {
+	getter http.Getter
+	now    func() time.Time
+}
+
+// minimumMinorVersion is the first Alpine 3.x release with nocloud images.
+const minimumMinorVersion = 18
+
+var branchRex = regexp.MustCompile(`^v3\.(?P<minor>[0-9]+)$`)
+
+const homepageURL = "https://alpinelinux.org/"
+
+const licenses = "LicenseRef-Alpine-Linux"
+
+//nolint:lll
+var description = `<img src="https://alpinelinux.org/alpinelinux-logo.svg" alt="drawing" height="15"/> Alpine Linux nocloud images for KubeVirt.
+<br />
+<br />
+Visit [alpinelinux.org](https://alpinelinux.org/) to learn more about Alpine Linux.`
+
+func (a *alpine) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:                   "alpine",
+		Version:                a.Version,
+		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
+		ExampleUserDataPayload: a.UserData(&docs.UserData{}),
+		DefaultInstancetype:    "u1.small",
+		DefaultPreference:      "alpine",
+	}
+}
+
+func (a *alpine) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	releases, err := getReleases(ctx, a.getter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting releases: %v", err)
+	}
+
+	pointRelease := ""
+	for i := range releases.ReleaseBranches {
+		if releases.ReleaseBranches[i].RelBranch == "v"+a.Version {
+			pointRelease = latestPointRelease(&releases.ReleaseBranches[i])
+			break
+		}
+	}
+	if pointRelease == "" {
+		return nil, fmt.Errorf("no release information in releases.json for alpine:%q found", a.Version)
+	}
+
+	baseURL := fmt.Sprintf("https://dl-cdn.alpinelinux.org/alpine/v%s/releases/cloud/", a.Version)
+	fileName := fmt.Sprintf("nocloud_alpine-%s-%s-%s-%s.qcow2", pointRelease, a.Arch, a.Variant, a.Revision)
+	checksumURL := baseURL + fileName + ".sha512"
+	raw, err := a.getter.GetAllWithContext(ctx, checksumURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the alpine checksum file: %v", err)
+	}
+	_, checksum, err := hashsum.ParseSingle(bytes.NewReader(raw), hashsum.ChecksumFormatGNU)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the alpine checksum file: %v", err)
+	}
+
+	return &api.ArtifactDetails{
+		SHA512Sum:            checksum,
+		ChecksumURL:          checksumURL,
+		DownloadURL:          baseURL + fileName,
+		AdditionalUniqueTags: []string{pointRelease},
+	}, nil
+}
+
+func (a *alpine) VM(name, imgRef, userData string) *v1.VirtualMachine {
+	return docs.NewVM(
+		name,
+		imgRef,
+		docs.WithRng(),
+		docs.WithCloudInitNoCloud(userData),
+	)
+}
+
+func (a *alpine) UserData(data *docs.UserData) string {
+	return docs.CloudInit(data)
+}
+
+func (a *alpine) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{
+		tests.SSH,
+	}
+}
+
+func (g *alpineGatherer) Gather(ctx context.Context) ([]api.Artifact, error) {
+	releases, err := getReleases(ctx, g.getter)
+	if err != nil {
+		return nil, fmt.Errorf("error getting releases: %v", err)
+	}
+
+	minors := []int{}
+	for i := range releases.ReleaseBranches {
+		if minor, ok := g.branchMatches(&releases.ReleaseBranches[i]); ok {
+			minors = append(minors, minor)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(minors)))
+
+	artifacts := []api.Artifact{}
+	for _, minor := range minors {
+		artifacts = append(artifacts, New(fmt.Sprintf("3.%d", minor)))
+	}
+
+	return artifacts, nil
+}
+
+func getReleases(ctx context.Context, getter http.Getter) (*Releases, error) {
+	raw, err := getter.GetAllWithContext(ctx, releasesURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the alpine releases.json file: %v", err)
+	}
+
+	releases := &Releases{}
+	if err := json.Unmarshal(raw, releases); err != nil {
+		return nil, fmt.Errorf("error parsing the releases.json file: %v", err)
+	}
+
+	return releases, nil
+}
+
+// latestPointRelease returns the point release with the highest patch version of a branch.
+func latestPointRelease(branch *ReleaseBranch) string {
+	latest := ""
+	latestPatch := -1
+	for _, release := range branch.Releases {
+		components := strings.Split(release.Version, ".")
+		patch, err := strconv.Atoi(components[len(components)-1])
+		if err == nil && patch > latestPatch {
+			latest = release.Version
+			latestPatch = patch
+		}
+	}
+
+	return latest
+}
+
+func (g *alpineGatherer) branchMatches(branch *ReleaseBranch) (minor int, matches bool) {
+	m := branchRex.FindStringSubmatch(branch.RelBranch)
+	if m == nil || len(branch.Releases) == 0 {
+		return 0, false
+	}
+
+	minor, err := strconv.Atoi(m[1])
+	if err != nil || minor < minimumMinorVersion {
+		return 0, false
+	}
+
+	eol, err := time.Parse("2006-01-02", branch.EOLDate)
+	return minor, err == nil && g.now().Before(eol)
+}
+
+// New accepts Alpine 3.x release branches like 3.18.
+func New(release string) *alpine {
+	return &alpine{
+		Version:  release,
+		Arch:     "x86_64",
+		Variant:  "bios-tiny",
+		Revision: "r0",
+		getter:   http.DefaultGetter,
+	}
+}
+
+func NewGatherer() *alpineGatherer {
+	return &alpineGatherer{
+		getter: http.DefaultGetter,
+		now:    time.Now,
+	}
+}
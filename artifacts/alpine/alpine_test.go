@@ -0,0 +1,77 @@
+package alpine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Alpine", func() {
+	It("Inspect should be able to parse releases and checksum files", func() {
+		c := New("3.18")
+		c.getter = testutil.NewMockGetterWithFiles(map[string]string{
+			"https://alpinelinux.org/releases.json": "testdata/releases.json",
+			"https://dl-cdn.alpinelinux.org/alpine/v3.18/releases/cloud/nocloud_alpine-3.18.10-x86_64-bios-tiny-r0.qcow2.sha512": "testdata/nocloud_alpine-3.18.10-x86_64-bios-tiny-r0.qcow2.sha512", //nolint:lll
+		})
+		got, err := c.Inspect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(&api.ArtifactDetails{
+			SHA512Sum:            "6e4a0b419dbee804996480ab462a7cce01175ef114c39f16021f9cca1a3faf0058c2fdc7914a0ea9df7bd83eb1248815fc413f197627a77a3deb3d9ff0709dbe", //nolint:lll
+			ChecksumURL:          "https://dl-cdn.alpinelinux.org/alpine/v3.18/releases/cloud/nocloud_alpine-3.18.10-x86_64-bios-tiny-r0.qcow2.sha512",               //nolint:lll
+			DownloadURL:          "https://dl-cdn.alpinelinux.org/alpine/v3.18/releases/cloud/nocloud_alpine-3.18.10-x86_64-bios-tiny-r0.qcow2",
+			AdditionalUniqueTags: []string{"3.18.10"},
+		}))
+		Expect(c.Metadata()).To(Equal(&api.Metadata{
+			Name:                   "alpine",
+			Version:                "3.18",
+			Description:            description,
+			URL:                    homepageURL,
+			Licenses:               licenses,
+			ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+			DefaultInstancetype:    "u1.small",
+			DefaultPreference:      "alpine",
+		}))
+	})
+
+	It("Inspect should fail for unknown releases", func() {
+		c := New("3.99")
+		c.getter = testutil.NewMockGetter("testdata/releases.json")
+		_, err := c.Inspect(context.Background())
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("Gather should only return supported releases",
+		func(now string, versions []string) {
+			c := NewGatherer()
+			c.getter = testutil.NewMockGetter("testdata/releases.json")
+			c.now = func() time.Time {
+				t, err := time.Parse("2006-01-02", now)
+				Expect(err).NotTo(HaveOccurred())
+				return t
+			}
+
+			artifacts := []api.Artifact{}
+			for _, version := range versions {
+				artifacts = append(artifacts, New(version))
+			}
+
+			got, err := c.Gather(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(artifacts))
+		},
+		Entry("before the 3.18 EOL", "2024-10-01", []string{"3.19", "3.18"}),
+		Entry("after the 3.18 EOL", "2025-06-01", []string{"3.19"}),
+	)
+})
+
+func TestAlpine(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Alpine Suite")
+}
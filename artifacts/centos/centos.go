@@ -2,8 +2,9 @@ package centos
 
 import (
 	"bytes"
+	"context"
+	_ "embed"
 	"fmt"
-	"sort"
 	"strings"
 
 	v1 "kubevirt.io/api/core/v1"
@@ -11,20 +12,36 @@ import (
 	"kubevirt.io/containerdisks/pkg/docs"
 	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const homepageURL = "https://www.centos.org/"
+
+const licenses = "LicenseRef-CentOS"
+
 //nolint:lll
 var description = `<img src="https://upload.wikimedia.org/wikipedia/commons/thumb/9/9e/CentOS_Graphical_Symbol.svg/64px-CentOS_Graphical_Symbol.svg.png" alt="drawing" height="15"/> Centos Generic Cloud images for KubeVirt.
 <br />
 <br />
 Visit [centos.org](https://www.centos.org/) to learn more about the CentOS project.`
 
+//go:embed keys.asc
+var keys []byte
+
+// keyring contains the keys which sign the CentOS 7 and CentOS 8 checksum files.
+var keyring = pgp.MustNewKeyring("centos", keys,
+	"6341 AB27 53D7 8A78 A7C2 7BB1 24C6 A8A7 F4A8 0EB5", // CentOS 7
+	"99DB 70FA E1D7 CE22 7FB6 4882 05B5 55B3 8483 C65D", // CentOS 8
+)
+
 type centos struct {
-	Version string
-	Variant string
-	getter  http.Getter
-	Arch    string
+	Version   string
+	Variant   string
+	getter    http.Getter
+	Arch      string
+	signature *pgp.Signature
 }
 
 func (c *centos) Metadata() *api.Metadata {
@@ -32,14 +49,18 @@ func (c *centos) Metadata() *api.Metadata {
 		Name:                   "centos",
 		Version:                c.Version,
 		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
 		ExampleUserDataPayload: c.UserData(&docs.UserData{}),
+		DefaultInstancetype:    "u1.medium",
+		DefaultPreference:      defaultPreference(c.Version),
 	}
 }
 
-func (c *centos) Inspect() (*api.ArtifactDetails, error) {
+func (c *centos) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
 	baseURL, checksumURL, checksumFormat := getURLsAndChecksumFormat(c.Version)
 
-	raw, err := c.getter.GetAll(checksumURL)
+	raw, err := c.signature.Fetch(ctx, c.getter, checksumURL)
 	if err != nil {
 		return nil, fmt.Errorf("error downloading the centos checksum file: %v", err)
 	}
@@ -53,12 +74,15 @@ func (c *centos) Inspect() (*api.ArtifactDetails, error) {
 		return nil, fmt.Errorf("no candidates for version %q and variant %q found", c.Version, c.Variant)
 	}
 
-	candidate := candidates[len(candidates)-1]
+	candidate := version.Newest(candidates, version.CompareRPM)
 	if checksum, exists := checksums[candidate]; exists {
+		additionalTags, movingTags := getAdditionalTags(c.Version, c.Variant, candidate)
 		return &api.ArtifactDetails{
 			SHA256Sum:            checksum,
+			ChecksumURL:          checksumURL,
 			DownloadURL:          baseURL + candidate,
-			AdditionalUniqueTags: getAdditionalTags(c.Version, c.Variant, candidate),
+			AdditionalUniqueTags: additionalTags,
+			MovingTags:           movingTags,
 		}, nil
 	}
 
@@ -73,7 +97,7 @@ func getURLsAndChecksumFormat(version string) (baseURL string, checksumURL strin
 		checksumFormat = hashsum.ChecksumFormatBSD
 	case strings.HasPrefix(version, "7-"):
 		baseURL = "https://cloud.centos.org/centos/7/images/"
-		checksumURL = baseURL + "sha256sum.txt"
+		checksumURL = baseURL + "sha256sum.txt.asc"
 		checksumFormat = hashsum.ChecksumFormatGNU
 	default:
 		panic(fmt.Sprintf("can't understand provided version: %q", version))
@@ -82,6 +106,14 @@ func getURLsAndChecksumFormat(version string) (baseURL string, checksumURL strin
 	return
 }
 
+func defaultPreference(version string) string {
+	// There is no dedicated preference for CentOS 8, RHEL 8 is the closest match
+	if strings.HasPrefix(version, "8.") {
+		return "rhel.8"
+	}
+	return "centos.7"
+}
+
 func getCandidates(version, variant string, checksums map[string]string) (candidates []string) {
 	switch {
 	case strings.HasPrefix(version, "8."):
@@ -100,12 +132,12 @@ func getCandidates(version, variant string, checksums map[string]string) (candid
 		}
 	}
 
-	sort.Strings(candidates)
-
 	return
 }
 
-func getAdditionalTags(version, variant, candidate string) (additionalTags []string) {
+// getAdditionalTags returns the compose tag like 8.4.2105-20210603.0 and the release tag like
+// 8.4.2105. The release tag moves to newer composes of the release.
+func getAdditionalTags(version, variant, candidate string) (additionalTags, movingTags []string) {
 	// The CentOS 8 version is expected to contain one dash
 	const expectedCentos8VersionPartsCount = 2
 
@@ -115,6 +147,7 @@ func getAdditionalTags(version, variant, candidate string) (additionalTags []str
 		split := strings.Split(additionalTag, "-")
 		if len(split) == expectedCentos8VersionPartsCount {
 			additionalTags = append(additionalTags, split[0])
+			movingTags = append(movingTags, split[0])
 		}
 	}
 
@@ -144,9 +177,10 @@ func (c *centos) Tests() []api.ArtifactTest {
 // New accepts CentOS 7 and 8 versions. Example patterns are 7-2111, 7-2009, 8.3, 8.4, ...
 func New(release string) *centos {
 	return &centos{
-		Version: release,
-		Arch:    "x86_64",
-		Variant: "GenericCloud",
-		getter:  &http.HTTPGetter{},
+		Version:   release,
+		Arch:      "x86_64",
+		Variant:   "GenericCloud",
+		getter:    http.DefaultGetter,
+		signature: &pgp.Signature{Keyring: keyring},
 	}
 }
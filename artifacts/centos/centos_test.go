@@ -1,22 +1,41 @@
 package centos
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to sign test data
+	"golang.org/x/crypto/openpgp"
 
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/testutil"
 )
 
 var _ = Describe("Centos", func() {
+	var (
+		signer      *openpgp.Entity
+		testKeyring *pgp.Keyring
+	)
+
+	BeforeEach(func() {
+		var err error
+		signer, testKeyring, err = testutil.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
 	DescribeTable("Inspect should be able to parse checksum files",
 		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
+			signedFile, err := testutil.ClearsignFile(signer, mockFile, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
 			c := New(release)
-			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			c.signature = &pgp.Signature{Keyring: testKeyring}
+			c.getter = testutil.NewMockGetter(signedFile)
+			got, err := c.Inspect(context.Background())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got).To(Equal(details))
 			Expect(c.Metadata()).To(Equal(metadata))
@@ -24,56 +43,107 @@ var _ = Describe("Centos", func() {
 		Entry("centos:8.4", "8.4", "testdata/centos8.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "3510fc7deb3e1939dbf3fe6f65a02ab1efcc763480bc352e4c06eca2e4f7c2a2",
+				ChecksumURL:          "https://cloud.centos.org/centos/8/x86_64/images/CHECKSUM",
 				DownloadURL:          "https://cloud.centos.org/centos/8/x86_64/images/CentOS-8-GenericCloud-8.4.2105-20210603.0.x86_64.qcow2",
 				Compression:          "",
 				AdditionalUniqueTags: []string{"8.4.2105-20210603.0", "8.4.2105"},
+				MovingTags:           []string{"8.4.2105"},
 			},
 			&api.Metadata{
 				Name:                   "centos",
 				Version:                "8.4",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "rhel.8",
 			},
 		),
 		Entry("centos:8.3", "8.3", "testdata/centos8.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "7ec97062618dc0a7ebf211864abf63629da1f325578868579ee70c495bed3ba0",
+				ChecksumURL:          "https://cloud.centos.org/centos/8/x86_64/images/CHECKSUM",
 				DownloadURL:          "https://cloud.centos.org/centos/8/x86_64/images/CentOS-8-GenericCloud-8.3.2011-20201204.2.x86_64.qcow2",
 				Compression:          "",
 				AdditionalUniqueTags: []string{"8.3.2011-20201204.2", "8.3.2011"},
+				MovingTags:           []string{"8.3.2011"},
 			},
 			&api.Metadata{
 				Name:                   "centos",
 				Version:                "8.3",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "rhel.8",
 			},
 		),
 		Entry("centos:7-2009", "7-2009", "testdata/centos7.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:   "e38bab0475cc6d004d2e17015969c659e5a308111851b0e2715e84646035bdd3",
+				ChecksumURL: "https://cloud.centos.org/centos/7/images/sha256sum.txt.asc",
 				DownloadURL: "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud-2009.qcow2",
 			},
 			&api.Metadata{
 				Name:                   "centos",
 				Version:                "7-2009",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "centos.7",
 			},
 		),
 		Entry("centos:7-1809", "7-1809", "testdata/centos7.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:   "42c062df8a8c36991ec0282009dd52ac488461a3f7ee114fc21a765bfc2671c2",
+				ChecksumURL: "https://cloud.centos.org/centos/7/images/sha256sum.txt.asc",
 				DownloadURL: "https://cloud.centos.org/centos/7/images/CentOS-7-x86_64-GenericCloud-1809.qcow2",
 			},
 			&api.Metadata{
 				Name:                   "centos",
 				Version:                "7-1809",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "centos.7",
 			},
 		),
 	)
+
+	DescribeTable("Inspect should verify signed checksum files",
+		func(release, mockFile, sha256Sum string) {
+			signedFile, err := testutil.ClearsignFile(signer, mockFile, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
+			c := New(release)
+			Expect(c.signature.Keyring).To(Equal(keyring))
+			c.signature = &pgp.Signature{Keyring: testKeyring}
+			c.getter = testutil.NewMockGetter(signedFile)
+			details, err := c.Inspect(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(details.SHA256Sum).To(Equal(sha256Sum))
+
+			c.getter = testutil.NewMockGetter(mockFile)
+			_, err = c.Inspect(context.Background())
+			Expect(err).To(MatchError(ContainSubstring("no clearsigned message found")))
+		},
+		Entry("centos:8.4", "8.4", "testdata/centos8.checksum", "3510fc7deb3e1939dbf3fe6f65a02ab1efcc763480bc352e4c06eca2e4f7c2a2"),
+		Entry("centos:7-2009", "7-2009", "testdata/centos7.checksum", "e38bab0475cc6d004d2e17015969c659e5a308111851b0e2715e84646035bdd3"),
+	)
+
+	It("Inspect should fail without a keyring", func() {
+		c := New("7-2009")
+		c.signature = nil
+		c.getter = testutil.NewMockGetter("testdata/centos7.checksum")
+		_, err := c.Inspect(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("no keyring")))
+	})
 })
 
 func TestCentos(t *testing.T) {
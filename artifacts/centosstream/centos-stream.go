@@ -2,53 +2,88 @@ package centosstream
 
 import (
 	"bytes"
+	"context"
+	_ "embed"
 	"fmt"
-	"sort"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	v1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/discovery"
 	"kubevirt.io/containerdisks/pkg/docs"
 	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const homepageURL = "https://www.centos.org/centos-stream/"
+
+const licenses = "LicenseRef-CentOS"
+
 //nolint:lll
 var description = `<img src="https://upload.wikimedia.org/wikipedia/commons/thumb/9/9e/CentOS_Graphical_Symbol.svg/64px-CentOS_Graphical_Symbol.svg.png" alt="drawing" height="15"/> Centos Stream Generic Cloud images for KubeVirt.
 <br />
 <br />
 Visit [centos.org](https://www.centos.org/) to learn more about the CentOS project.`
 
+//go:embed keys.asc
+var keys []byte
+
+// keyring contains the CentOS Official Signing Key, which signs the CentOS Stream checksum files.
+var keyring = pgp.MustNewKeyring("centos", keys, "99DB 70FA E1D7 CE22 7FB6 4882 05B5 55B3 8483 C65D")
+
 type centos struct {
-	Version string
-	Variant string
-	getter  http.Getter
-	Arch    string
+	Version   string
+	Variant   string
+	getter    http.Getter
+	Arch      string
+	signature *pgp.Signature
+}
+
+type centosGatherer struct {
+	getter http.Getter
+	now    func() time.Time
 }
 
+const centosURL = "https://cloud.centos.org/centos/"
+
+// eolDates contains the end of life dates of CentOS Stream releases. Releases which
+// are not listed here are considered to be supported.
+var eolDates = map[int]string{
+	8: "2024-05-31",
+	9: "2027-05-31",
+}
+
+var streamDirRex = regexp.MustCompile(`^(?P<version>[0-9]+)-stream$`)
+
 func (c *centos) Metadata() *api.Metadata {
 	return &api.Metadata{
 		Name:                   "centos-stream",
 		Version:                c.Version,
 		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
 		ExampleUserDataPayload: c.UserData(&docs.UserData{}),
+		DefaultInstancetype:    "u1.medium",
+		DefaultPreference:      "centos.stream" + c.Version,
 	}
 }
 
-func (c *centos) Inspect() (*api.ArtifactDetails, error) {
-	var baseURL string
-
-	if strings.HasPrefix(c.Version, "8") || strings.HasPrefix(c.Version, "9") {
-		baseURL = fmt.Sprintf("https://cloud.centos.org/centos/%s-stream/x86_64/images/", c.Version)
-	} else {
-		panic(fmt.Sprintf("can't understand provided version: %q", c.Version))
+func (c *centos) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	if _, err := strconv.Atoi(c.Version); err != nil {
+		return nil, fmt.Errorf("can't understand provided version: %q", c.Version)
 	}
 
+	baseURL := fmt.Sprintf("%s%s-stream/%s/images/", centosURL, c.Version, c.Arch)
 	checksumURL := baseURL + "CHECKSUM"
 	checksumFormat := hashsum.ChecksumFormatBSD
 
-	raw, err := c.getter.GetAll(checksumURL)
+	raw, err := c.signature.Fetch(ctx, c.getter, checksumURL)
 	if err != nil {
 		return nil, fmt.Errorf("error downloading the centos stream checksum file: %v", err)
 	}
@@ -68,8 +103,7 @@ func (c *centos) Inspect() (*api.ArtifactDetails, error) {
 		return nil, fmt.Errorf("no candidates for version %q and variant %q found", c.Version, c.Variant)
 	}
 
-	sort.Strings(candidates)
-	candidate := candidates[len(candidates)-1]
+	candidate := version.Newest(candidates, version.CompareRPM)
 
 	var additionalTags []string
 	additionalTag := strings.TrimSuffix(strings.TrimPrefix(candidate, fmt.Sprintf("CentOS-Stream-%s-", c.Variant)), ".x86_64.qcow2")
@@ -78,6 +112,7 @@ func (c *centos) Inspect() (*api.ArtifactDetails, error) {
 	if checksum, exists := checksums[candidate]; exists {
 		return &api.ArtifactDetails{
 			SHA256Sum:            checksum,
+			ChecksumURL:          checksumURL,
 			DownloadURL:          baseURL + candidate,
 			AdditionalUniqueTags: additionalTags,
 		}, nil
@@ -106,12 +141,79 @@ func (c *centos) Tests() []api.ArtifactTest {
 	}
 }
 
-// New accepts CentOS Stream 8 and 9 versions.
+func (g *centosGatherer) Gather(ctx context.Context) ([]api.Artifact, error) {
+	links, err := discovery.Find(ctx, g.getter, centosURL, streamDirRex, version.CompareRPM)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the centos releases: %v", err)
+	}
+
+	artifacts := []api.Artifact{}
+	for i := range links {
+		version, err := strconv.Atoi(links[i].Key())
+		if err != nil || !g.isSupported(version) {
+			continue
+		}
+
+		artifact := New(links[i].Key())
+		hasImage, err := g.hasImage(ctx, artifact)
+		if err != nil {
+			return nil, err
+		}
+		if hasImage {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+
+	return artifacts, nil
+}
+
+// hasImage returns true if the images directory of a stream contains a qcow2 image of
+// the variant of artifact. New streams are announced before their images are published.
+// A missing images directory means that there is no image.
+func (g *centosGatherer) hasImage(ctx context.Context, artifact *centos) (bool, error) {
+	imageRex := regexp.MustCompile(fmt.Sprintf(`^CentOS-Stream-%s-%s-.*\.%s\.qcow2$`,
+		regexp.QuoteMeta(artifact.Variant), regexp.QuoteMeta(artifact.Version), regexp.QuoteMeta(artifact.Arch)))
+	links, err := discovery.List(ctx, g.getter, fmt.Sprintf("%s%s-stream/%s/images/", centosURL, artifact.Version, artifact.Arch))
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, nil
+	}
+
+	for i := range links {
+		if !links[i].IsDir && imageRex.MatchString(links[i].Name) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (g *centosGatherer) isSupported(version int) bool {
+	eolDate, exists := eolDates[version]
+	if !exists {
+		return true
+	}
+
+	eol, err := time.Parse("2006-01-02", eolDate)
+	return err == nil && g.now().Before(eol)
+}
+
+// New accepts CentOS Stream versions like 8, 9 or 10.
 func New(release string) *centos {
 	return &centos{
-		Version: release,
-		Arch:    "x86_64",
-		Variant: "GenericCloud",
-		getter:  &http.HTTPGetter{},
+		Version:   release,
+		Arch:      "x86_64",
+		Variant:   "GenericCloud",
+		getter:    http.DefaultGetter,
+		signature: &pgp.Signature{Keyring: keyring},
+	}
+}
+
+func NewGatherer() *centosGatherer {
+	return &centosGatherer{
+		getter: http.DefaultGetter,
+		now:    time.Now,
 	}
 }
@@ -1,22 +1,32 @@
 package centosstream
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/testutil"
 )
 
 var _ = Describe("CentosStream", func() {
 	DescribeTable("Inspect should be able to parse checksum files",
 		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
+			signer, testKeyring, err := testutil.NewSigner()
+			Expect(err).NotTo(HaveOccurred())
+			signedFile, err := testutil.ClearsignFile(signer, mockFile, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
 			c := New(release)
-			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			Expect(c.signature.Keyring).To(Equal(keyring))
+			c.signature = &pgp.Signature{Keyring: testKeyring}
+			c.getter = testutil.NewMockGetter(signedFile)
+			got, err := c.Inspect(context.Background())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got).To(Equal(details))
 			Expect(c.Metadata()).To(Equal(metadata))
@@ -24,6 +34,7 @@ var _ = Describe("CentosStream", func() {
 		Entry("centos-stream:8", "8", "testdata/centos-stream8.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "8e22e67687b81e38c7212fc30c47cb24cbc4935c0f2459ed139f498397d1e7cd",
+				ChecksumURL:          "https://cloud.centos.org/centos/8-stream/x86_64/images/CHECKSUM",
 				DownloadURL:          "https://cloud.centos.org/centos/8-stream/x86_64/images/CentOS-Stream-GenericCloud-8-20210603.0.x86_64.qcow2",
 				AdditionalUniqueTags: []string{"8-20210603.0"},
 			},
@@ -31,12 +42,17 @@ var _ = Describe("CentosStream", func() {
 				Name:                   "centos-stream",
 				Version:                "8",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "centos.stream8",
 			},
 		),
 		Entry("centos-stream:9", "9", "testdata/centos-stream9.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "bcebdc00511d6e18782732570056cfbc7cba318302748bfc8f66be9c0db68142",
+				ChecksumURL:          "https://cloud.centos.org/centos/9-stream/x86_64/images/CHECKSUM",
 				DownloadURL:          "https://cloud.centos.org/centos/9-stream/x86_64/images/CentOS-Stream-GenericCloud-9-20211222.0.x86_64.qcow2",
 				AdditionalUniqueTags: []string{"9-20211222.0"},
 			},
@@ -44,12 +60,61 @@ var _ = Describe("CentosStream", func() {
 				Name:                   "centos-stream",
 				Version:                "9",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "centos.stream9",
 			},
 		),
 	)
+
+	It("Inspect should fail for versions which are not a number", func() {
+		c := New("stream")
+		c.getter = testutil.NewMockGetter("testdata/centos-stream9.checksum")
+		_, err := c.Inspect(context.Background())
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("Gather should return supported releases with images newest first",
+		func(now string, images map[string]string, versions []string) {
+			files := map[string]string{centosURL: "testdata/index.html"}
+			for version, file := range images {
+				files[centosURL+version+"-stream/x86_64/images/"] = file
+			}
+
+			c := NewGatherer()
+			c.getter = testutil.NewMockGetterWithFiles(files)
+			c.now = func() time.Time {
+				t, err := time.Parse("2006-01-02", now)
+				Expect(err).NotTo(HaveOccurred())
+				return t
+			}
+
+			artifacts := []api.Artifact{}
+			for _, version := range versions {
+				artifacts = append(artifacts, New(version))
+			}
+
+			got, err := c.Gather(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(artifacts))
+		},
+		Entry("before the stream 8 EOL", "2024-01-01", allImages, []string{"9", "8"}),
+		Entry("after the stream 8 EOL", "2024-06-01", allImages, []string{"9"}),
+		Entry("with images of another stream", "2024-06-01",
+			map[string]string{"8": "testdata/images-8.html", "9": "testdata/images-9.html", "10": "testdata/images-9.html"},
+			[]string{"9"}),
+		Entry("without an images directory", "2024-01-01", map[string]string{"8": "testdata/images-8.html"}, []string{"8"}),
+	)
 })
 
+var allImages = map[string]string{
+	"8":  "testdata/images-8.html",
+	"9":  "testdata/images-9.html",
+	"10": "testdata/images-10.html",
+}
+
 func TestCentosStream(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "CentosStream Suite")
@@ -0,0 +1,233 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/tests"
+)
+
+// Subcommands of the external provider protocol. The provider binary is executed with
+// a subcommand followed by its configured arguments. Requests are passed as JSON on
+// stdin, responses are expected as JSON on stdout.
+const (
+	// SubcommandMetadata returns the Metadata response.
+	SubcommandMetadata = "metadata"
+	// SubcommandInspect returns an api.ArtifactDetails.
+	SubcommandInspect = "inspect"
+	// SubcommandVM reads a VMRequest and returns a v1.VirtualMachine.
+	SubcommandVM = "vm"
+	// SubcommandUserData reads a docs.UserData and returns the raw user data.
+	SubcommandUserData = "userdata"
+)
+
+// Metadata is the response of the metadata subcommand. Tests contains the names of the
+// tests which run during verification, see knownTests.
+type Metadata struct {
+	api.Metadata
+	Tests []string `json:",omitempty"`
+}
+
+// VMRequest is passed to the vm subcommand.
+type VMRequest struct {
+	Name     string
+	ImgRef   string
+	UserData string
+}
+
+// DefaultTimeout limits how long a single call of the provider may take.
+const DefaultTimeout = 1 * time.Minute
+
+var knownTests = map[string]api.ArtifactTest{
+	"GuestOsInfo": tests.GuestOsInfo,
+	"SSH":         tests.SSH,
+}
+
+type external struct {
+	Command string
+	Args    []string
+	// Timeout limits every call of the provider, including calls without a context like VM.
+	Timeout  time.Duration
+	metadata *Metadata
+	tests    []api.ArtifactTest
+}
+
+func (e *external) Metadata() *api.Metadata {
+	return &e.metadata.Metadata
+}
+
+func (e *external) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	details := &api.ArtifactDetails{}
+	if err := e.call(ctx, SubcommandInspect, nil, details); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// VM returns nil if the provider fails to create the VirtualMachine, see CreateVM. New rejects
+// providers which can't create a VirtualMachine, so this only happens on later failures.
+// The call is bounded by Timeout.
+func (e *external) VM(name, imgRef, userData string) *v1.VirtualMachine {
+	vm, err := e.CreateVM(context.Background(), name, imgRef, userData)
+	if err != nil {
+		logrus.WithField("provider", e.Command).Error(err)
+		return nil
+	}
+
+	return vm
+}
+
+// UserData returns an empty string if the provider fails to create the user data, see CreateUserData.
+// New rejects providers which can't create user data, so this only happens on later failures.
+// The call is bounded by Timeout.
+func (e *external) UserData(data *docs.UserData) string {
+	userData, err := e.CreateUserData(context.Background(), data)
+	if err != nil {
+		logrus.WithField("provider", e.Command).Error(err)
+		return ""
+	}
+
+	return userData
+}
+
+// CreateVM returns the VirtualMachine of the provider or an error including its stderr.
+func (e *external) CreateVM(ctx context.Context, name, imgRef, userData string) (*v1.VirtualMachine, error) {
+	vm := &v1.VirtualMachine{}
+	if err := e.call(ctx, SubcommandVM, &VMRequest{Name: name, ImgRef: imgRef, UserData: userData}, vm); err != nil {
+		return nil, err
+	}
+
+	return vm, nil
+}
+
+// CreateUserData returns the raw user data of the provider or an error including its stderr.
+func (e *external) CreateUserData(ctx context.Context, data *docs.UserData) (string, error) {
+	raw, err := e.execute(ctx, SubcommandUserData, data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(raw), nil
+}
+
+func (e *external) Tests() []api.ArtifactTest {
+	return e.tests
+}
+
+func (e *external) call(ctx context.Context, subcommand string, request, response interface{}) error {
+	raw, err := e.execute(ctx, subcommand, request)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(raw, response); err != nil {
+		return fmt.Errorf("error parsing the response of %q %s: %v", e.Command, subcommand, err)
+	}
+
+	return nil
+}
+
+func (e *external) execute(ctx context.Context, subcommand string, request interface{}) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.Timeout)
+	defer cancel()
+
+	var stdin []byte
+	if request != nil {
+		var err error
+		if stdin, err = json.Marshal(request); err != nil {
+			return nil, fmt.Errorf("error marshaling the request for %q %s: %v", e.Command, subcommand, err)
+		}
+	}
+
+	raw, err := run(ctx, stdin, e.Command, append([]string{subcommand}, e.Args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("error running %q %s: %v", e.Command, subcommand, err)
+	}
+
+	return raw, nil
+}
+
+func (e *external) load(ctx context.Context) error {
+	metadata := &Metadata{}
+	if err := e.call(ctx, SubcommandMetadata, nil, metadata); err != nil {
+		return err
+	}
+	if metadata.Name == "" || metadata.Version == "" {
+		return fmt.Errorf("provider %q returned metadata without name or version", e.Command)
+	}
+
+	artifactTests := []api.ArtifactTest{}
+	for _, name := range metadata.Tests {
+		test, exists := knownTests[name]
+		if !exists {
+			return fmt.Errorf("provider %q requested unknown test %q", e.Command, name)
+		}
+		artifactTests = append(artifactTests, test)
+	}
+
+	// A provider which can't create the user data or the VM is rejected up front instead of
+	// producing empty docs later. The user data for empty data is the example of the docs.
+	userData, err := e.CreateUserData(ctx, &docs.UserData{})
+	if err != nil {
+		return err
+	}
+	if metadata.ExampleUserDataPayload == "" {
+		metadata.ExampleUserDataPayload = userData
+	}
+	if _, err = e.CreateVM(ctx, metadata.Name, metadata.Describe(), metadata.ExampleUserDataPayload); err != nil {
+		return err
+	}
+
+	e.metadata = metadata
+	e.tests = artifactTests
+
+	return nil
+}
+
+// run executes command with args, passes stdin to it and returns its stdout.
+// The command is killed when ctx is done.
+func run(ctx context.Context, stdin []byte, command string, args ...string) ([]byte, error) {
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.CommandContext(ctx, command, args...) //nolint:gosec
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// New accepts a provider command line like "/usr/bin/provider --image internal".
+// The metadata of the provider is loaded immediately and the provider has to create
+// the example user data and a VirtualMachine, else it is rejected.
+func New(ctx context.Context, commandLine string) (*external, error) {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty provider command line")
+	}
+
+	e := &external{
+		Command: fields[0],
+		Args:    fields[1:],
+		Timeout: DefaultTimeout,
+	}
+	if err := e.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
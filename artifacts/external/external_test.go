@@ -0,0 +1,105 @@
+package external
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+)
+
+const provider = "testdata/provider.sh"
+
+var _ = Describe("External", func() {
+	It("New should load the metadata of the provider", func() {
+		e, err := New(context.Background(), provider+" --image internal")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.Command).To(Equal(provider))
+		Expect(e.Args).To(Equal([]string{"--image", "internal"}))
+		Expect(e.Metadata()).To(Equal(&api.Metadata{
+			Name:        "internal",
+			Version:     "1.0",
+			Description: "Internal images",
+			// Created by the provider for empty user data
+			ExampleUserDataPayload: "#cloud-config\nuser: ",
+		}))
+		Expect(e.Tests()).To(HaveLen(1))
+	})
+
+	DescribeTable("New should fail",
+		func(commandLine string) {
+			_, err := New(context.Background(), commandLine)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("with an empty command line", " "),
+		Entry("with a missing provider", "testdata/missing.sh"),
+		Entry("with an unknown test", provider+" --unknown-test"),
+		Entry("with a provider which can't create the user data", provider+" --failing"),
+		Entry("with a provider which can't create the VM", provider+" --failing-vm"),
+	)
+
+	It("Inspect should return the artifact details of the provider", func() {
+		e, err := New(context.Background(), provider)
+		Expect(err).NotTo(HaveOccurred())
+		details, err := e.Inspect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(details).To(Equal(&api.ArtifactDetails{
+			SHA256Sum:            "abc",
+			DownloadURL:          "https://mirror.example.org/internal-1.0.qcow2",
+			AdditionalUniqueTags: []string{"1.0.3"},
+		}))
+	})
+
+	It("VM should return the VirtualMachine of the provider", func() {
+		e, err := New(context.Background(), provider)
+		Expect(err).NotTo(HaveOccurred())
+		vm := e.VM("internal-vm", "quay.io/containerdisks/internal:1.0", "")
+		Expect(vm).NotTo(BeNil())
+		Expect(vm.Name).To(Equal("internal-vm"))
+		Expect(vm.Kind).To(Equal("VirtualMachine"))
+	})
+
+	It("UserData should return the raw user data of the provider", func() {
+		e, err := New(context.Background(), provider)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(e.UserData(&docs.UserData{Username: "verify"})).To(Equal("#cloud-config\nuser: verify"))
+	})
+
+	It("CreateVM should return the stderr of a provider which fails after it was loaded", func() {
+		e, err := New(context.Background(), provider)
+		Expect(err).NotTo(HaveOccurred())
+		e.Args = []string{"--failing"}
+		_, err = api.CreateVM(context.Background(), e, "internal-vm", "quay.io/containerdisks/internal:1.0", "")
+		Expect(err).To(MatchError(ContainSubstring("no template for internal")))
+		Expect(e.VM("internal-vm", "quay.io/containerdisks/internal:1.0", "")).To(BeNil())
+	})
+
+	It("CreateUserData should return the stderr of a provider which fails after it was loaded", func() {
+		e, err := New(context.Background(), provider)
+		Expect(err).NotTo(HaveOccurred())
+		e.Args = []string{"--failing"}
+		_, err = api.CreateUserData(context.Background(), e, &docs.UserData{Username: "verify"})
+		Expect(err).To(MatchError(ContainSubstring("no user data for internal")))
+		Expect(e.UserData(&docs.UserData{Username: "verify"})).To(BeEmpty())
+	})
+
+	It("VM and UserData should give up on a hanging provider after the timeout", func() {
+		e, err := New(context.Background(), provider)
+		Expect(err).NotTo(HaveOccurred())
+		e.Args = []string{"--hanging"}
+		e.Timeout = 100 * time.Millisecond
+		Expect(e.VM("internal-vm", "quay.io/containerdisks/internal:1.0", "")).To(BeNil())
+		Expect(e.UserData(&docs.UserData{Username: "verify"})).To(BeEmpty())
+		_, err = api.CreateVM(context.Background(), e, "internal-vm", "quay.io/containerdisks/internal:1.0", "")
+		Expect(err).To(MatchError(ContainSubstring("signal: killed")))
+	})
+})
+
+func TestExternal(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "External Suite")
+}
@@ -0,0 +1,48 @@
+#!/bin/sh
+# Minimal external provider used by the tests. The first argument is the
+# subcommand, the remaining arguments are the configured provider arguments.
+set -e
+
+subcommand="$1"
+shift
+
+case "${subcommand}" in
+metadata)
+	if [ "$1" = "--unknown-test" ]; then
+		echo '{"Name": "internal", "Version": "1.0", "Tests": ["Unknown"]}'
+	else
+		echo '{"Name": "internal", "Version": "1.0", "Description": "Internal images", "Tests": ["SSH"]}'
+	fi
+	;;
+inspect)
+	echo '{"SHA256Sum": "abc", "DownloadURL": "https://mirror.example.org/internal-1.0.qcow2", "AdditionalUniqueTags": ["1.0.3"]}'
+	;;
+vm)
+	if [ "$1" = "--failing" ] || [ "$1" = "--failing-vm" ]; then
+		echo "no template for internal" >&2
+		exit 1
+	fi
+	if [ "$1" = "--hanging" ]; then
+		exec sleep 60
+	fi
+	request="$(cat)"
+	name="$(printf "%s" "${request}" | sed -e 's/.*"Name":"\([^"]*\)".*/\1/')"
+	echo "{\"kind\": \"VirtualMachine\", \"apiVersion\": \"kubevirt.io/v1\", \"metadata\": {\"name\": \"${name}\"}}"
+	;;
+userdata)
+	if [ "$1" = "--failing" ]; then
+		echo "no user data for internal" >&2
+		exit 1
+	fi
+	if [ "$1" = "--hanging" ]; then
+		exec sleep 60
+	fi
+	request="$(cat)"
+	username="$(printf "%s" "${request}" | sed -e 's/.*"Username":"\([^"]*\)".*/\1/')"
+	printf '#cloud-config\nuser: %s' "${username}"
+	;;
+*)
+	echo "unknown subcommand ${subcommand}" >&2
+	exit 1
+	;;
+esac
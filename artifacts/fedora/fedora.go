@@ -1,16 +1,23 @@
 package fedora
 
 import (
+	"bytes"
+	"context"
+	_ "embed"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
 	v1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
 type Releases []Release
@@ -26,10 +33,11 @@ type Release struct {
 }
 
 type fedora struct {
-	Version string
-	Arch    string
-	Variant string
-	getter  http.Getter
+	Version   string
+	Arch      string
+	Variant   string
+	getter    http.Getter
+	signature *pgp.Signature
 }
 
 type fedoraGatherer struct {
@@ -40,6 +48,29 @@ type fedoraGatherer struct {
 
 const minimumVersion = 35
 
+const releasesURL = "https://getfedora.org/releases.json"
+
+//go:embed keys.asc
+var keys []byte
+
+// keyring contains the primary keys of the Fedora releases, which sign the checksum
+// files of their images. The key of every new release has to be added here.
+var keyring = pgp.MustNewKeyring("fedora", keys,
+	"E8F2 3996 F232 1864 0CB4 4CBE 75CF 5AC4 18B8 E74C", // Fedora 39
+	"115D F9AE F857 853E E844 5D0A 0727 707E A15B 79CC", // Fedora 40
+	"466C F2D8 B60B C305 7AA9 453E D062 2462 E99D 6AD1", // Fedora 41
+	"B0F4 9504 58F6 9E11 50C6 C5ED C8AC 4916 105E F944", // Fedora 42
+	"C6E7 F081 CF80 E131 4667 6E88 829B 6066 3164 5531", // Fedora 43
+	"36F6 12DC F27F 7D1A 48A8 35E4 DBFC F71C 6D9F 90A6", // Fedora 44
+)
+
+// imageRex matches the release and compose of cloud images like Fedora-Cloud-Base-38-1.6.x86_64.qcow2.
+var imageRex = regexp.MustCompile(`-(?P<release>[0-9]+)-(?P<compose>[0-9.]+)\.(?P<arch>[a-z0-9_]+)\.qcow2$`)
+
+const homepageURL = "https://fedoraproject.org/"
+
+const licenses = "LicenseRef-Fedora"
+
 //nolint:lll
 var description string = `<img src="https://upload.wikimedia.org/wikipedia/commons/thumb/3/3f/Fedora_logo.svg/240px-Fedora_logo.svg.png" alt="drawing" width="15"/> Fedora [Cloud](https://alt.fedoraproject.org/cloud/) images for KubeVirt.
 <br />
@@ -51,39 +82,93 @@ func (f *fedora) Metadata() *api.Metadata {
 		Name:                   "fedora",
 		Version:                f.Version,
 		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
 		ExampleUserDataPayload: f.UserData(&docs.UserData{}),
+		DefaultInstancetype:    "u1.medium",
+		DefaultPreference:      "fedora",
 	}
 }
 
-func (f *fedora) Inspect() (*api.ArtifactDetails, error) {
-	releases, err := getReleases(f.getter)
+func (f *fedora) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	releases, err := getReleases(ctx, f.getter)
 	if err != nil {
 		return nil, fmt.Errorf("error getting releases: %v", err)
 	}
 
-	for i, release := range releases {
+	for i := range releases {
 		if f.releaseMatches(&releases[i]) {
-			components := strings.Split(release.Link, "/")
-			fileName := components[len(components)-1]
-			additionalTag := strings.TrimSuffix(strings.TrimPrefix(fileName, "Fedora-Cloud-Base-"), ".x86_64.qcow2")
-
-			return &api.ArtifactDetails{
-				SHA256Sum:            release.Sha256,
-				DownloadURL:          release.Link,
-				AdditionalUniqueTags: []string{additionalTag},
-			}, nil
+			return f.inspectRelease(ctx, &releases[i])
 		}
 	}
 
 	return nil, fmt.Errorf("no release information in releases.json for fedora:%q found", f.Version)
 }
 
+// inspectRelease verifies the checksum of a release in releases.json with the signed
+// checksum file of its compose.
+func (f *fedora) inspectRelease(ctx context.Context, release *Release) (*api.ArtifactDetails, error) {
+	components := strings.Split(release.Link, "/")
+	fileName := components[len(components)-1]
+	additionalTag := strings.TrimSuffix(strings.TrimPrefix(fileName, "Fedora-Cloud-Base-"), ".x86_64.qcow2")
+
+	checksumURL, err := getChecksumURL(release.Link)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := f.getChecksum(ctx, checksumURL, fileName)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != release.Sha256 {
+		return nil, fmt.Errorf("checksum of %q in releases.json does not match the signed checksum file", fileName)
+	}
+
+	return &api.ArtifactDetails{
+		SHA256Sum:            checksum,
+		ChecksumURL:          checksumURL,
+		DownloadURL:          release.Link,
+		AdditionalUniqueTags: []string{additionalTag},
+	}, nil
+}
+
+// getChecksumURL returns the URL of the signed checksum file next to an image, e.g.
+// Fedora-Cloud-38-1.6-x86_64-CHECKSUM for Fedora-Cloud-Base-38-1.6.x86_64.qcow2.
+func getChecksumURL(link string) (string, error) {
+	matches := imageRex.FindStringSubmatch(link)
+	if matches == nil {
+		return "", fmt.Errorf("can't find the checksum file of %q", link)
+	}
+
+	return fmt.Sprintf("%s/Fedora-Cloud-%s-%s-%s-CHECKSUM", link[:strings.LastIndex(link, "/")],
+		matches[imageRex.SubexpIndex("release")], matches[imageRex.SubexpIndex("compose")], matches[imageRex.SubexpIndex("arch")]), nil
+}
+
+func (f *fedora) getChecksum(ctx context.Context, checksumURL, fileName string) (string, error) {
+	raw, err := f.signature.Fetch(ctx, f.getter, checksumURL)
+	if err != nil {
+		return "", fmt.Errorf("error downloading the fedora checksum file: %v", err)
+	}
+	checksums, err := hashsum.Parse(bytes.NewReader(raw), hashsum.ChecksumFormatBSD)
+	if err != nil {
+		return "", fmt.Errorf("error reading the fedora checksum file: %v", err)
+	}
+
+	checksum, exists := checksums[fileName]
+	if !exists {
+		return "", fmt.Errorf("file %q does not exist in the checksum file", fileName)
+	}
+
+	return checksum, nil
+}
+
 func (f *fedora) VM(name, imgRef, userData string) *v1.VirtualMachine {
 	return docs.NewVM(
 		name,
 		imgRef,
 		docs.WithRng(),
 		docs.WithCloudInitNoCloud(userData),
+		docs.WithInstancetypeInference(),
 		docs.WithSecureBoot(),
 	)
 }
@@ -99,24 +184,30 @@ func (f *fedora) Tests() []api.ArtifactTest {
 	}
 }
 
-func (f *fedoraGatherer) Gather() ([]api.Artifact, error) {
-	releases, err := getReleases(f.getter)
+func (f *fedoraGatherer) Gather(ctx context.Context) ([]api.Artifact, error) {
+	releases, err := getReleases(ctx, f.getter)
 	if err != nil {
 		return nil, fmt.Errorf("error getting releases: %v", err)
 	}
 
-	artifacts := []api.Artifact{}
+	versions := []string{}
 	for i, release := range releases {
 		if f.releaseMatches(&releases[i]) {
-			artifacts = append(artifacts, New(release.Version))
+			versions = append(versions, release.Version)
 		}
 	}
+	version.SortDescending(versions, version.CompareRPM)
+
+	artifacts := []api.Artifact{}
+	for _, release := range versions {
+		artifacts = append(artifacts, New(release))
+	}
 
 	return artifacts, nil
 }
 
-func getReleases(getter http.Getter) (Releases, error) {
-	raw, err := getter.GetAll("https://getfedora.org/releases.json")
+func getReleases(ctx context.Context, getter http.Getter) (Releases, error) {
+	raw, err := getter.GetAllWithContext(ctx, releasesURL)
 	if err != nil {
 		return nil, fmt.Errorf("error downloading the fedora releases.json file: %v", err)
 	}
@@ -146,10 +237,11 @@ func (f *fedoraGatherer) releaseMatches(release *Release) bool {
 
 func New(release string) *fedora {
 	return &fedora{
-		Version: release,
-		Arch:    "x86_64",
-		Variant: "Cloud",
-		getter:  &http.HTTPGetter{},
+		Version:   release,
+		Arch:      "x86_64",
+		Variant:   "Cloud",
+		getter:    http.DefaultGetter,
+		signature: &pgp.Signature{Keyring: keyring},
 	}
 }
 
@@ -157,6 +249,6 @@ func NewGatherer() *fedoraGatherer {
 	return &fedoraGatherer{
 		Arch:    "x86_64",
 		Variant: "Cloud",
-		getter:  &http.HTTPGetter{},
+		getter:  http.DefaultGetter,
 	}
 }
@@ -1,30 +1,56 @@
 package fedora
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to sign test data
+	"golang.org/x/crypto/openpgp"
 
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/testutil"
 )
 
 var _ = Describe("Fedora", func() {
+	var (
+		signer      *openpgp.Entity
+		testKeyring *pgp.Keyring
+	)
+
+	BeforeEach(func() {
+		var err error
+		signer, testKeyring, err = testutil.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	newMockGetter := func(checksumURL, checksumFile string) http.Getter {
+		signedFile, err := testutil.ClearsignFile(signer, checksumFile, GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		return testutil.NewMockGetterWithFiles(map[string]string{
+			releasesURL: "testdata/releases.json",
+			checksumURL: signedFile,
+		})
+	}
+
 	DescribeTable("Inspect should be able to parse releases files",
-		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
+		func(release, checksumFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
 			c := New(release)
-			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			c.signature = &pgp.Signature{Keyring: testKeyring}
+			c.getter = newMockGetter(details.ChecksumURL, checksumFile)
+			got, err := c.Inspect(context.Background())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got).To(Equal(details))
 			Expect(c.Metadata()).To(Equal(metadata))
 		},
-		Entry("fedora:35", "35", "testdata/releases.json",
+		Entry("fedora:35", "35", "testdata/Fedora-Cloud-35-1.2-x86_64-CHECKSUM",
 			&api.ArtifactDetails{
 				SHA256Sum:            "fe84502779b3477284a8d4c86731f642ca10dd3984d2b5eccdf82630a9ca2de6",
+				ChecksumURL:          "https://download.fedoraproject.org/pub/fedora/linux/releases/35/Cloud/x86_64/images/Fedora-Cloud-35-1.2-x86_64-CHECKSUM",   //nolint:lll
 				DownloadURL:          "https://download.fedoraproject.org/pub/fedora/linux/releases/35/Cloud/x86_64/images/Fedora-Cloud-Base-35-1.2.x86_64.qcow2", //nolint:lll
 				AdditionalUniqueTags: []string{"35-1.2"},
 			},
@@ -32,12 +58,17 @@ var _ = Describe("Fedora", func() {
 				Name:                   "fedora",
 				Version:                "35",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "fedora",
 			},
 		),
-		Entry("fedora:34", "34", "testdata/releases.json",
+		Entry("fedora:34", "34", "testdata/Fedora-Cloud-34-1.2-x86_64-CHECKSUM",
 			&api.ArtifactDetails{
 				SHA256Sum:            "b9b621b26725ba95442d9a56cbaa054784e0779a9522ec6eafff07c6e6f717ea",
+				ChecksumURL:          "https://download.fedoraproject.org/pub/fedora/linux/releases/34/Cloud/x86_64/images/Fedora-Cloud-34-1.2-x86_64-CHECKSUM",   //nolint:lll
 				DownloadURL:          "https://download.fedoraproject.org/pub/fedora/linux/releases/34/Cloud/x86_64/images/Fedora-Cloud-Base-34-1.2.x86_64.qcow2", //nolint:lll
 				AdditionalUniqueTags: []string{"34-1.2"},
 			},
@@ -45,30 +76,54 @@ var _ = Describe("Fedora", func() {
 				Name:                   "fedora",
 				Version:                "34",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "fedora",
 			},
 		),
 	)
 
+	It("Inspect should reject checksums which differ from the signed checksum file", func() {
+		const checksumURL = "https://download.fedoraproject.org/pub/fedora/linux/releases/35/Cloud/x86_64/images/Fedora-Cloud-35-1.2-x86_64-CHECKSUM"
+
+		c := New("35")
+		Expect(c.signature.Keyring).To(Equal(keyring))
+		c.signature = &pgp.Signature{Keyring: testKeyring}
+		c.getter = newMockGetter(checksumURL, "testdata/Fedora-Cloud-34-1.2-x86_64-CHECKSUM")
+		_, err := c.Inspect(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("does not exist in the checksum file")))
+
+		c.getter = testutil.NewMockGetterWithFiles(map[string]string{
+			releasesURL: "testdata/releases.json",
+			checksumURL: "testdata/Fedora-Cloud-35-1.2-x86_64-CHECKSUM",
+		})
+		_, err = c.Inspect(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("no clearsigned message found")))
+	})
+
 	It("Gather should be able to parse releases files", func() {
 		artifacts := []api.Artifact{
 			&fedora{
-				Version: "36",
-				Arch:    "x86_64",
-				Variant: "Cloud",
-				getter:  &http.HTTPGetter{},
+				Version:   "36",
+				Arch:      "x86_64",
+				Variant:   "Cloud",
+				getter:    http.DefaultGetter,
+				signature: &pgp.Signature{Keyring: keyring},
 			},
 			&fedora{
-				Version: "35",
-				Arch:    "x86_64",
-				Variant: "Cloud",
-				getter:  &http.HTTPGetter{},
+				Version:   "35",
+				Arch:      "x86_64",
+				Variant:   "Cloud",
+				getter:    http.DefaultGetter,
+				signature: &pgp.Signature{Keyring: keyring},
 			},
 		}
 
 		c := NewGatherer()
 		c.getter = testutil.NewMockGetter("testdata/releases.json")
-		got, err := c.Gather()
+		got, err := c.Gather(context.Background())
 		Expect(err).NotTo(HaveOccurred())
 		Expect(got).To(Equal(artifacts))
 	})
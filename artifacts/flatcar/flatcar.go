@@ -0,0 +1,130 @@
+package flatcar
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/containers/image/v5/pkg/compression/types"
+	v1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/tests"
+)
+
+type flatcar struct {
+	Channel     string
+	Variant     string
+	getter      http.Getter
+	Arch        string
+	Compression string
+}
+
+const homepageURL = "https://www.flatcar.org/"
+
+const licenses = "LicenseRef-Flatcar"
+
+//nolint:lll
+var description string = `Flatcar Container Linux images for KubeVirt.
+<br />
+<br />
+Visit [flatcar.org](https://www.flatcar.org/) to learn more about Flatcar Container Linux.`
+
+func (f *flatcar) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:                   "flatcar",
+		Version:                f.Channel,
+		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
+		ExampleUserDataPayload: f.UserData(&docs.UserData{}),
+	}
+}
+
+func (f *flatcar) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	channelURL := fmt.Sprintf("https://%s.release.flatcar-linux.net/%s-usr/", f.Channel, f.Arch)
+
+	// Resolve the current release first, so the checksum and the image are read from the
+	// same immutable release directory
+	raw, err := f.getter.GetAllWithContext(ctx, channelURL+"current/version.txt")
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the flatcar version.txt file: %v", err)
+	}
+	version, err := parseVersion(raw)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the flatcar version.txt file: %v", err)
+	}
+
+	baseURL := channelURL + version + "/"
+	checksumURL := baseURL + f.Variant + ".DIGESTS"
+	raw, err = f.getter.GetAllWithContext(ctx, checksumURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the flatcar DIGESTS file: %v", err)
+	}
+	checksums, err := hashsum.ParseDigests(bytes.NewReader(raw), "sha256")
+	if err != nil {
+		return nil, fmt.Errorf("error reading the flatcar DIGESTS file: %v", err)
+	}
+	if checksum, exists := checksums[f.Variant]; exists {
+		return &api.ArtifactDetails{
+			SHA256Sum:            checksum,
+			ChecksumURL:          checksumURL,
+			DownloadURL:          baseURL + f.Variant,
+			Compression:          f.Compression,
+			AdditionalUniqueTags: []string{version},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("file %q does not exist in the DIGESTS file", f.Variant)
+}
+
+func parseVersion(raw []byte) (string, error) {
+	const versionKey = "FLATCAR_VERSION="
+
+	s := bufio.NewScanner(bytes.NewReader(raw))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, versionKey) {
+			return strings.TrimPrefix(line, versionKey), nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+
+	return "", fmt.Errorf("no %s found", strings.TrimSuffix(versionKey, "="))
+}
+
+func (f *flatcar) VM(name, imgRef, userData string) *v1.VirtualMachine {
+	return docs.NewVM(
+		name,
+		imgRef,
+		docs.WithRng(),
+		docs.WithCloudInitConfigDrive(userData),
+	)
+}
+
+func (f *flatcar) UserData(data *docs.UserData) string {
+	return docs.Ignition(data)
+}
+
+func (f *flatcar) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{
+		tests.SSH,
+	}
+}
+
+// New accepts the Flatcar channels stable, beta and alpha.
+func New(channel string) *flatcar {
+	return &flatcar{
+		Channel:     channel,
+		Arch:        "amd64",
+		Variant:     "flatcar_production_qemu_image.img.bz2",
+		getter:      http.DefaultGetter,
+		Compression: types.Bzip2AlgorithmName,
+	}
+}
@@ -0,0 +1,52 @@
+package flatcar
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Flatcar", func() {
+	It("Inspect should be able to parse version and DIGESTS files", func() {
+		c := New("stable")
+		c.getter = testutil.NewMockGetterWithFiles(map[string]string{
+			"https://stable.release.flatcar-linux.net/amd64-usr/current/version.txt":                                    "testdata/version-stable.txt",
+			"https://stable.release.flatcar-linux.net/amd64-usr/3510.2.0/flatcar_production_qemu_image.img.bz2.DIGESTS": "testdata/stable.DIGESTS",
+		})
+		got, err := c.Inspect(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(&api.ArtifactDetails{
+			SHA256Sum:            "a0e3e2bd5dbee1a6b5a4a8b1bcd6c6ae4b0d4f83e8a1a3fa0c1e1bb1f7a6d2c9",
+			ChecksumURL:          "https://stable.release.flatcar-linux.net/amd64-usr/3510.2.0/flatcar_production_qemu_image.img.bz2.DIGESTS", //nolint:lll
+			DownloadURL:          "https://stable.release.flatcar-linux.net/amd64-usr/3510.2.0/flatcar_production_qemu_image.img.bz2",
+			Compression:          "bzip2",
+			AdditionalUniqueTags: []string{"3510.2.0"},
+		}))
+		Expect(c.Metadata()).To(Equal(&api.Metadata{
+			Name:                   "flatcar",
+			Version:                "stable",
+			Description:            description,
+			URL:                    homepageURL,
+			Licenses:               licenses,
+			ExampleUserDataPayload: docs.Ignition(&docs.UserData{}),
+		}))
+	})
+
+	It("Inspect should fail if version.txt contains no version", func() {
+		c := New("stable")
+		c.getter = testutil.NewMockGetter("testdata/version-broken.txt")
+		_, err := c.Inspect(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("no FLATCAR_VERSION found")))
+	})
+})
+
+func TestFlatcar(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Flatcar Suite")
+}
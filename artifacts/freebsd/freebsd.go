@@ -0,0 +1,99 @@
+package freebsd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/containers/image/v5/pkg/compression/types"
+	v1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/tests"
+)
+
+type freebsd struct {
+	Version     string
+	Variant     string
+	getter      http.Getter
+	Arch        string
+	Compression string
+}
+
+const homepageURL = "https://www.freebsd.org/"
+
+const licenses = "BSD-2-Clause"
+
+//nolint:lll
+var description string = `FreeBSD BASIC-CLOUDINIT images for KubeVirt.
+<br />
+<br />
+Visit [freebsd.org](https://www.freebsd.org/) to learn more about the FreeBSD project.`
+
+func (f *freebsd) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:                   "freebsd",
+		Version:                f.Version,
+		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
+		ExampleUserDataPayload: f.UserData(&docs.UserData{}),
+	}
+}
+
+func (f *freebsd) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	baseURL := fmt.Sprintf("https://download.freebsd.org/releases/VM-IMAGES/%s-RELEASE/%s/Latest/", f.Version, f.Arch)
+	checksumURL := baseURL + "CHECKSUM.SHA256"
+	raw, err := f.getter.GetAllWithContext(ctx, checksumURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the freebsd CHECKSUM.SHA256 file: %v", err)
+	}
+	checksums, err := hashsum.Parse(bytes.NewReader(raw), hashsum.ChecksumFormatBSD)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the CHECKSUM.SHA256 file: %v", err)
+	}
+
+	fileName := fmt.Sprintf("FreeBSD-%s-RELEASE-%s-%s.qcow2.xz", f.Version, f.Arch, f.Variant)
+	if checksum, exists := checksums[fileName]; exists {
+		return &api.ArtifactDetails{
+			SHA256Sum:   checksum,
+			ChecksumURL: checksumURL,
+			DownloadURL: baseURL + fileName,
+			Compression: f.Compression,
+		}, nil
+	}
+	return nil, fmt.Errorf("file %q does not exist in the CHECKSUM.SHA256 file", fileName)
+}
+
+func (f *freebsd) VM(name, imgRef, userData string) *v1.VirtualMachine {
+	return docs.NewVM(
+		name,
+		imgRef,
+		docs.WithRng(),
+		docs.WithCloudInitNoCloud(userData),
+	)
+}
+
+func (f *freebsd) UserData(data *docs.UserData) string {
+	return docs.CloudInitFreeBSD(data)
+}
+
+// Tests only contains the SSH test, because FreeBSD ships without qemu-guest-agent.
+func (f *freebsd) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{
+		tests.SSH,
+	}
+}
+
+// New accepts FreeBSD release versions like 13.2 and 14.0.
+func New(release string) *freebsd {
+	return &freebsd{
+		Version:     release,
+		Arch:        "amd64",
+		Variant:     "BASIC-CLOUDINIT-ufs",
+		getter:      http.DefaultGetter,
+		Compression: types.XzAlgorithmName,
+	}
+}
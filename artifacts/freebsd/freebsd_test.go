@@ -0,0 +1,53 @@
+package freebsd
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("FreeBSD", func() {
+	DescribeTable("Inspect should be able to parse checksum files",
+		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
+			c := New(release)
+			c.getter = testutil.NewMockGetter(mockFile)
+			got, err := c.Inspect(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(details))
+			Expect(c.Metadata()).To(Equal(metadata))
+		},
+		Entry("freebsd:13.2", "13.2", "testdata/CHECKSUM.SHA256",
+			&api.ArtifactDetails{
+				SHA256Sum:   "34b780165bb8e1a512e96a855d701c9068241a5b3208a571122789b661cc62d1",
+				ChecksumURL: "https://download.freebsd.org/releases/VM-IMAGES/13.2-RELEASE/amd64/Latest/CHECKSUM.SHA256",
+				DownloadURL: "https://download.freebsd.org/releases/VM-IMAGES/13.2-RELEASE/amd64/Latest/FreeBSD-13.2-RELEASE-amd64-BASIC-CLOUDINIT-ufs.qcow2.xz", //nolint:lll
+				Compression: "Xz",
+			},
+			&api.Metadata{
+				Name:                   "freebsd",
+				Version:                "13.2",
+				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
+				ExampleUserDataPayload: docs.CloudInitFreeBSD(&docs.UserData{}),
+			},
+		),
+	)
+
+	It("UserData should not depend on sudo", func() {
+		userData := New("13.2").UserData(&docs.UserData{Username: "verify"})
+		Expect(userData).To(ContainSubstring("groups: wheel"))
+		Expect(userData).ToNot(ContainSubstring("sudo"))
+	})
+})
+
+func TestFreeBSD(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "FreeBSD Suite")
+}
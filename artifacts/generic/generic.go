@@ -1,6 +1,8 @@
 package generic
 
 import (
+	"context"
+
 	v1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
@@ -15,7 +17,7 @@ func (c *generic) Metadata() *api.Metadata {
 	return c.metadata
 }
 
-func (c *generic) Inspect() (*api.ArtifactDetails, error) {
+func (c *generic) Inspect(_ context.Context) (*api.ArtifactDetails, error) {
 	return c.artifactDetails, nil
 }
 
@@ -0,0 +1,134 @@
+package opensuse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+
+	v1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/hashsum"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/tests"
+)
+
+const tumbleweed = "tumbleweed"
+
+const homepageURL = "https://www.opensuse.org/"
+
+const licenses = "LicenseRef-openSUSE"
+
+//nolint:lll
+var description = `<img src="https://upload.wikimedia.org/wikipedia/commons/thumb/d/d0/OpenSUSE_Logo.svg/240px-OpenSUSE_Logo.svg.png" alt="drawing" width="15"/> openSUSE Leap and Tumbleweed Minimal-VM images for KubeVirt.
+<br />
+<br />
+Visit [opensuse.org](https://www.opensuse.org/) to learn more about the openSUSE project.`
+
+var snapshotRex = regexp.MustCompile(`-Snapshot(?P<snapshot>[0-9]+)\.`)
+
+type opensuse struct {
+	Version string
+	Variant string
+	getter  http.Getter
+	Arch    string
+}
+
+func (o *opensuse) Metadata() *api.Metadata {
+	return &api.Metadata{
+		Name:                   "opensuse",
+		Version:                o.Version,
+		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
+		ExampleUserDataPayload: o.UserData(&docs.UserData{}),
+		DefaultInstancetype:    "u1.medium",
+		DefaultPreference:      o.defaultPreference(),
+	}
+}
+
+func (o *opensuse) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	baseURL, fileName := o.getURLAndFileName()
+
+	// openSUSE publishes a checksum file per image. The file name in the checksum
+	// file points to the build behind the moving file name.
+	checksumURL := baseURL + fileName + ".sha256"
+	raw, err := o.getter.GetAllWithContext(ctx, checksumURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the opensuse checksum file: %v", err)
+	}
+	candidate, checksum, err := hashsum.ParseSingle(bytes.NewReader(raw), hashsum.ChecksumFormatGNU)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the opensuse checksum file: %v", err)
+	}
+
+	var additionalTags []string
+	if o.Version == tumbleweed {
+		matches := snapshotRex.FindStringSubmatch(candidate)
+		if matches == nil {
+			return nil, fmt.Errorf("no snapshot found in file %q", candidate)
+		}
+		additionalTags = append(additionalTags, fmt.Sprintf("%s-%s", tumbleweed, matches[1]))
+	}
+
+	return &api.ArtifactDetails{
+		SHA256Sum:            checksum,
+		ChecksumURL:          checksumURL,
+		DownloadURL:          baseURL + candidate,
+		AdditionalUniqueTags: additionalTags,
+	}, nil
+}
+
+func (o *opensuse) getURLAndFileName() (baseURL, fileName string) {
+	if o.Version == tumbleweed {
+		baseURL = "https://download.opensuse.org/tumbleweed/appliances/"
+		fileName = fmt.Sprintf("openSUSE-Tumbleweed-Minimal-VM.%s-%s.qcow2", o.Arch, o.Variant)
+	} else {
+		baseURL = fmt.Sprintf("https://download.opensuse.org/distribution/leap/%s/appliances/", o.Version)
+		fileName = fmt.Sprintf("openSUSE-Leap-%s-Minimal-VM.%s-%s.qcow2", o.Version, o.Arch, o.Variant)
+	}
+
+	return
+}
+
+func (o *opensuse) defaultPreference() string {
+	if o.Version == tumbleweed {
+		return "opensuse.tumbleweed"
+	}
+	return "opensuse.leap"
+}
+
+func (o *opensuse) VM(name, imgRef, userData string) *v1.VirtualMachine {
+	return docs.NewVM(
+		name,
+		imgRef,
+		docs.WithRng(),
+		docs.WithCloudInitNoCloud(userData),
+	)
+}
+
+func (o *opensuse) UserData(data *docs.UserData) string {
+	return docs.CloudInit(data)
+}
+
+func (o *opensuse) Tests() []api.ArtifactTest {
+	return []api.ArtifactTest{
+		tests.SSH,
+	}
+}
+
+// New accepts openSUSE Leap versions like 15.4 and 15.5.
+func New(release string) *opensuse {
+	return &opensuse{
+		Version: release,
+		Arch:    "x86_64",
+		Variant: "Cloud",
+		getter:  http.DefaultGetter,
+	}
+}
+
+// NewTumbleweed returns the latest snapshot of the openSUSE Tumbleweed rolling release.
+func NewTumbleweed() *opensuse {
+	return New(tumbleweed)
+}
@@ -0,0 +1,65 @@
+package opensuse
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("openSUSE", func() {
+	DescribeTable("Inspect should be able to parse checksum files",
+		func(artifact *opensuse, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
+			artifact.getter = testutil.NewMockGetter(mockFile)
+			got, err := artifact.Inspect(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(details))
+			Expect(artifact.Metadata()).To(Equal(metadata))
+		},
+		Entry("opensuse:15.4", New("15.4"), "testdata/leap-15.4.sha256",
+			&api.ArtifactDetails{
+				SHA256Sum:   "2b1ad6b4d8c7c2fbd2b3f6f7d3d1b8c2fe4c3a8f1e7c96d3d2b8a2e9b0f6e5c1",
+				ChecksumURL: "https://download.opensuse.org/distribution/leap/15.4/appliances/openSUSE-Leap-15.4-Minimal-VM.x86_64-Cloud.qcow2.sha256",            //nolint:lll
+				DownloadURL: "https://download.opensuse.org/distribution/leap/15.4/appliances/openSUSE-Leap-15.4-Minimal-VM.x86_64-15.4.0-Cloud-Build6.283.qcow2", //nolint:lll
+			},
+			&api.Metadata{
+				Name:                   "opensuse",
+				Version:                "15.4",
+				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
+				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "opensuse.leap",
+			},
+		),
+		Entry("opensuse:tumbleweed", NewTumbleweed(), "testdata/tumbleweed.sha256",
+			&api.ArtifactDetails{
+				SHA256Sum:            "6d7a1e4ba4fc5c0a1e0c0c3a6b0c2b7fa2f7e5f8a5a4c4d7e07dbc6bd1b2a0f3",
+				ChecksumURL:          "https://download.opensuse.org/tumbleweed/appliances/openSUSE-Tumbleweed-Minimal-VM.x86_64-Cloud.qcow2.sha256",                 //nolint:lll
+				DownloadURL:          "https://download.opensuse.org/tumbleweed/appliances/openSUSE-Tumbleweed-Minimal-VM.x86_64-1.0.0-Cloud-Snapshot20230419.qcow2", //nolint:lll
+				AdditionalUniqueTags: []string{"tumbleweed-20230419"},
+			},
+			&api.Metadata{
+				Name:                   "opensuse",
+				Version:                "tumbleweed",
+				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
+				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "opensuse.tumbleweed",
+			},
+		),
+	)
+})
+
+func TestOpenSUSE(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "openSUSE Suite")
+}
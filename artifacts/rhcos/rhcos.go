@@ -2,15 +2,19 @@ package rhcos
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"regexp"
 
 	"github.com/containers/image/v5/pkg/compression/types"
 	v1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/discovery"
 	"kubevirt.io/containerdisks/pkg/docs"
 	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
 type rhcos struct {
@@ -22,6 +26,22 @@ type rhcos struct {
 	AppendLatest bool
 }
 
+type rhcosGatherer struct {
+	// MinimumVersion is the oldest minor version which is gathered, e.g. "4.9".
+	MinimumVersion string
+	getter         http.Getter
+}
+
+const mirrorURL = "https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/"
+
+var majorMinorRex = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+var versionDirRex = regexp.MustCompile(`^(?P<version>[0-9]+\.[0-9]+)$`)
+
+const homepageURL = "https://docs.openshift.com/container-platform/latest/architecture/architecture-rhcos.html"
+
+const licenses = "LicenseRef-Red-Hat-CoreOS"
+
 //nolint:lll
 var description string = `RHCOS images for KubeVirt.
 <br />
@@ -33,17 +53,19 @@ func (r *rhcos) Metadata() *api.Metadata {
 		Name:                   "rhcos",
 		Version:                r.Version,
 		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
 		ExampleUserDataPayload: r.UserData(&docs.UserData{}),
 	}
 }
 
-func (r *rhcos) Inspect() (*api.ArtifactDetails, error) {
-	baseURL := fmt.Sprintf("https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/%s/", r.Version)
+func (r *rhcos) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	baseURL := fmt.Sprintf("%s%s/", mirrorURL, r.Version)
 	if r.AppendLatest {
 		baseURL += "latest/"
 	}
 	checksumURL := baseURL + "sha256sum.txt"
-	raw, err := r.getter.GetAll(checksumURL)
+	raw, err := r.getter.GetAllWithContext(ctx, checksumURL)
 	if err != nil {
 		return nil, fmt.Errorf("error downloading the rhcos sha256sum.txt file: %v", err)
 	}
@@ -54,6 +76,7 @@ func (r *rhcos) Inspect() (*api.ArtifactDetails, error) {
 	if checksum, exists := checksums[r.Variant]; exists {
 		return &api.ArtifactDetails{
 			SHA256Sum:            checksum,
+			ChecksumURL:          checksumURL,
 			DownloadURL:          baseURL + r.Variant,
 			Compression:          r.Compression,
 			AdditionalUniqueTags: []string{checksum},
@@ -82,13 +105,59 @@ func (r *rhcos) Tests() []api.ArtifactTest {
 	}
 }
 
+func (g *rhcosGatherer) Gather(ctx context.Context) ([]api.Artifact, error) {
+	versions, err := FindVersions(ctx, g.getter, mirrorURL, versionDirRex, g.MinimumVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []api.Artifact{}
+	for _, version := range versions {
+		artifacts = append(artifacts, New(version, true))
+	}
+
+	return artifacts, nil
+}
+
+// FindVersions returns all major.minor versions found by the first subexpression of rex
+// in the directory listing at indexURL, which are not below minimumVersion.
+// The result is sorted in descending order.
+func FindVersions(ctx context.Context, getter http.Getter, indexURL string, rex *regexp.Regexp, minimumVersion string) ([]string, error) {
+	if !majorMinorRex.MatchString(minimumVersion) {
+		return nil, fmt.Errorf("minimum version %q is not in the major.minor format", minimumVersion)
+	}
+
+	links, err := discovery.Find(ctx, getter, indexURL, rex, version.CompareSemver)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the rhcos versions: %v", err)
+	}
+
+	versions := []string{}
+	for i := range links {
+		if version.CompareSemver(links[i].Key(), minimumVersion) >= 0 {
+			versions = append(versions, links[i].Key())
+		}
+	}
+
+	return versions, nil
+}
+
 func New(release string, appendLatest bool) *rhcos {
 	return &rhcos{
 		Version:      release,
 		Arch:         "x86_64",
 		Variant:      "rhcos-openstack.x86_64.qcow2.gz",
-		getter:       &http.HTTPGetter{},
+		getter:       http.DefaultGetter,
 		Compression:  types.GzipAlgorithmName,
 		AppendLatest: appendLatest,
 	}
 }
+
+// NewGatherer returns a gatherer for all RHCOS minor versions on the mirror
+// which are not older than minimumVersion.
+func NewGatherer(minimumVersion string) *rhcosGatherer {
+	return &rhcosGatherer{
+		MinimumVersion: minimumVersion,
+		getter:         http.DefaultGetter,
+	}
+}
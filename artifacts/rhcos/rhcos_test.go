@@ -1,6 +1,7 @@
 package rhcos
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -16,7 +17,7 @@ var _ = Describe("Rhcos", func() {
 		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
 			c := New(release, true)
 			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			got, err := c.Inspect(context.Background())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got).To(Equal(details))
 			Expect(c.Metadata()).To(Equal(metadata))
@@ -24,6 +25,7 @@ var _ = Describe("Rhcos", func() {
 		Entry("rhcos:4.9", "4.9", "testdata/rhcos-4.9.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "3466690807fb710102559ea57daac0484c59ed4d914996882d601b8bb7a7ada8",
+				ChecksumURL:          "https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/4.9/latest/sha256sum.txt",
 				DownloadURL:          "https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/4.9/latest/rhcos-openstack.x86_64.qcow2.gz",
 				Compression:          "gzip",
 				AdditionalUniqueTags: []string{"3466690807fb710102559ea57daac0484c59ed4d914996882d601b8bb7a7ada8"},
@@ -32,12 +34,15 @@ var _ = Describe("Rhcos", func() {
 				Name:                   "rhcos",
 				Version:                "4.9",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.Ignition(&docs.UserData{}),
 			},
 		),
 		Entry("rhcos:4.8", "4.8", "testdata/rhcos-4.8.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "99da4ed945b391d452e55a3a7809c799e4c74f69acbee1ecaec78f368c4e369e",
+				ChecksumURL:          "https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/4.8/latest/sha256sum.txt",
 				DownloadURL:          "https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/4.8/latest/rhcos-openstack.x86_64.qcow2.gz",
 				Compression:          "gzip",
 				AdditionalUniqueTags: []string{"99da4ed945b391d452e55a3a7809c799e4c74f69acbee1ecaec78f368c4e369e"},
@@ -46,10 +51,36 @@ var _ = Describe("Rhcos", func() {
 				Name:                   "rhcos",
 				Version:                "4.8",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.Ignition(&docs.UserData{}),
 			},
 		),
 	)
+
+	DescribeTable("Gather should return all minor versions above the minimum version",
+		func(minimumVersion string, versions []string) {
+			artifacts := []api.Artifact{}
+			for _, version := range versions {
+				artifacts = append(artifacts, New(version, true))
+			}
+
+			c := NewGatherer(minimumVersion)
+			c.getter = testutil.NewMockGetter("testdata/rhcos.html")
+			got, err := c.Gather(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(artifacts))
+		},
+		Entry("from 4.9", "4.9", []string{"4.13", "4.12", "4.11", "4.10", "4.9"}),
+		Entry("from 4.12", "4.12", []string{"4.13", "4.12"}),
+	)
+
+	It("Gather should fail with an invalid minimum version", func() {
+		c := NewGatherer("4")
+		c.getter = testutil.NewMockGetter("testdata/rhcos.html")
+		_, err := c.Gather(context.Background())
+		Expect(err).To(HaveOccurred())
+	})
 })
 
 func TestRhcos(t *testing.T) {
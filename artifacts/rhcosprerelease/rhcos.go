@@ -2,11 +2,14 @@ package rhcosprerelease
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/containers/image/v5/pkg/compression/types"
 	v1 "kubevirt.io/api/core/v1"
+	rhcosrelease "kubevirt.io/containerdisks/artifacts/rhcos"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
 	"kubevirt.io/containerdisks/pkg/hashsum"
@@ -22,6 +25,20 @@ type rhcos struct {
 	Compression string
 }
 
+type rhcosGatherer struct {
+	// MinimumVersion is the oldest minor version which is gathered, e.g. "4.9".
+	MinimumVersion string
+	getter         http.Getter
+}
+
+const mirrorURL = "https://mirror.openshift.com/pub/openshift-v4/x86_64/dependencies/rhcos/pre-release/"
+
+var versionDirRex = regexp.MustCompile(`^latest-(?P<version>[0-9]+\.[0-9]+)$`)
+
+const homepageURL = "https://docs.openshift.com/container-platform/latest/architecture/architecture-rhcos.html"
+
+const licenses = "LicenseRef-Red-Hat-CoreOS"
+
 //nolint:lll
 var description string = `RHCOS prerelease images for KubeVirt.
 <br />
@@ -33,14 +50,16 @@ func (r *rhcos) Metadata() *api.Metadata {
 		Name:                   "rhcos",
 		Version:                strings.TrimPrefix(r.Version, "latest-") + "-pre-release",
 		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
 		ExampleUserDataPayload: r.UserData(&docs.UserData{}),
 	}
 }
 
-func (r *rhcos) Inspect() (*api.ArtifactDetails, error) {
-	baseURL := fmt.Sprintf("https://mirror.openshift.com/pub/openshift-v4/x86_64/dependencies/rhcos/pre-release/%s/", r.Version)
+func (r *rhcos) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	baseURL := fmt.Sprintf("%s%s/", mirrorURL, r.Version)
 	checksumURL := baseURL + "sha256sum.txt"
-	raw, err := r.getter.GetAll(checksumURL)
+	raw, err := r.getter.GetAllWithContext(ctx, checksumURL)
 	if err != nil {
 		return nil, fmt.Errorf("error downloading the rhcos sha256sum.txt file: %v", err)
 	}
@@ -53,6 +72,7 @@ func (r *rhcos) Inspect() (*api.ArtifactDetails, error) {
 	if checksum, exists := checksums[r.Variant]; exists {
 		artifact = &api.ArtifactDetails{
 			SHA256Sum:   checksum,
+			ChecksumURL: checksumURL,
 			DownloadURL: baseURL + r.Variant,
 			Compression: r.Compression,
 		}
@@ -97,12 +117,35 @@ func (r *rhcos) Tests() []api.ArtifactTest {
 	}
 }
 
+func (g *rhcosGatherer) Gather(ctx context.Context) ([]api.Artifact, error) {
+	versions, err := rhcosrelease.FindVersions(ctx, g.getter, mirrorURL, versionDirRex, g.MinimumVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := []api.Artifact{}
+	for _, version := range versions {
+		artifacts = append(artifacts, New("latest-"+version))
+	}
+
+	return artifacts, nil
+}
+
 func New(release string) *rhcos {
 	return &rhcos{
 		Version:     release,
 		Arch:        "x86_64",
 		Variant:     "rhcos-openstack.x86_64.qcow2.gz",
-		getter:      &http.HTTPGetter{},
+		getter:      http.DefaultGetter,
 		Compression: types.GzipAlgorithmName,
 	}
 }
+
+// NewGatherer returns a gatherer for the latest pre-releases of all RHCOS minor versions
+// on the mirror which are not older than minimumVersion.
+func NewGatherer(minimumVersion string) *rhcosGatherer {
+	return &rhcosGatherer{
+		MinimumVersion: minimumVersion,
+		getter:         http.DefaultGetter,
+	}
+}
@@ -1,6 +1,7 @@
 package rhcosprerelease
 
 import (
+	"context"
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -16,7 +17,7 @@ var _ = Describe("RhcosPrerelease", func() {
 		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
 			c := New(release)
 			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			got, err := c.Inspect(context.Background())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got).To(Equal(details))
 			Expect(c.Metadata()).To(Equal(metadata))
@@ -24,6 +25,7 @@ var _ = Describe("RhcosPrerelease", func() {
 		Entry("rhcos:4.9", "latest-4.9", "testdata/rhcos-latest-4.9-prerelease.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "3466690807fb710102559ea57daac0484c59ed4d914996882d601b8bb7a7ada8",
+				ChecksumURL:          "https://mirror.openshift.com/pub/openshift-v4/x86_64/dependencies/rhcos/pre-release/latest-4.9/sha256sum.txt",                   //nolint:lll
 				DownloadURL:          "https://mirror.openshift.com/pub/openshift-v4/x86_64/dependencies/rhcos/pre-release/latest-4.9/rhcos-openstack.x86_64.qcow2.gz", //nolint:lll
 				Compression:          "gzip",
 				AdditionalUniqueTags: []string{"4.9.0-rc.7", "3466690807fb710102559ea57daac0484c59ed4d914996882d601b8bb7a7ada8"},
@@ -32,12 +34,15 @@ var _ = Describe("RhcosPrerelease", func() {
 				Name:                   "rhcos",
 				Version:                "4.9-pre-release",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.Ignition(&docs.UserData{}),
 			},
 		),
 		Entry("rhcos:latest", "latest", "testdata/rhcos-latest-prerelease.checksum",
 			&api.ArtifactDetails{
 				SHA256Sum:            "f581896eee37216021bfce9ddd5e1fd8289c366ca0d1db25221c77688de85fd7",
+				ChecksumURL:          "https://mirror.openshift.com/pub/openshift-v4/x86_64/dependencies/rhcos/pre-release/latest/sha256sum.txt",                   //nolint:lll
 				DownloadURL:          "https://mirror.openshift.com/pub/openshift-v4/x86_64/dependencies/rhcos/pre-release/latest/rhcos-openstack.x86_64.qcow2.gz", //nolint:lll
 				Compression:          "gzip",
 				AdditionalUniqueTags: []string{"4.10.0-rc.1", "f581896eee37216021bfce9ddd5e1fd8289c366ca0d1db25221c77688de85fd7"},
@@ -46,10 +51,25 @@ var _ = Describe("RhcosPrerelease", func() {
 				Name:                   "rhcos",
 				Version:                "latest-pre-release",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.Ignition(&docs.UserData{}),
 			},
 		),
 	)
+
+	It("Gather should return the latest pre-release of all minor versions above the minimum version", func() {
+		artifacts := []api.Artifact{}
+		for _, version := range []string{"4.14", "4.13", "4.12", "4.11"} {
+			artifacts = append(artifacts, New("latest-"+version))
+		}
+
+		c := NewGatherer("4.11")
+		c.getter = testutil.NewMockGetter("testdata/rhcos-prerelease.html")
+		got, err := c.Gather(context.Background())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(artifacts))
+	})
 })
 
 func TestRhcosPrerelease(t *testing.T) {
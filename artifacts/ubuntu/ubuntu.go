@@ -1,25 +1,82 @@
 package ubuntu
 
 import (
-	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	v1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
-	"kubevirt.io/containerdisks/pkg/hashsum"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/pkg/tests"
+	"kubevirt.io/containerdisks/pkg/version"
 )
 
+const (
+	baseURL = "https://cloud-images.ubuntu.com/releases/"
+	// streamsURL points to the clearsigned variant of the simplestreams product file
+	streamsURL = baseURL + "streams/v1/com.ubuntu.cloud:released:download.sjson"
+)
+
+//go:embed keys.asc
+var keys []byte
+
+// keyring contains the key of the Ubuntu Cloud Image Builder, which signs the simplestreams files.
+var keyring = pgp.MustNewKeyring("ubuntu", keys, "4A3C E3CD 565D 7EB5 C810 E2B9 7FF3 F408 476C F100")
+
+// Streams is the subset of the simplestreams product file which is required to
+// find the latest serial of a release.
+type Streams struct {
+	Products map[string]Product `json:"products"`
+}
+
+type Product struct {
+	Arch         string             `json:"arch"`
+	Release      string             `json:"release"`
+	ReleaseTitle string             `json:"release_title"`
+	Version      string             `json:"version"`
+	Supported    bool               `json:"supported"`
+	SupportEOL   string             `json:"support_eol"`
+	Versions     map[string]Version `json:"versions"`
+}
+
+type Version struct {
+	Items map[string]Item `json:"items"`
+}
+
+type Item struct {
+	FileType string `json:"ftype"`
+	Path     string `json:"path"`
+	Sha256   string `json:"sha256"`
+}
+
 type ubuntu struct {
 	Version     string
 	Variant     string
 	getter      http.Getter
 	Arch        string
 	Compression string
+	signature   *pgp.Signature
 }
 
+type ubuntuGatherer struct {
+	Arch string
+	// LTS selects the long term support releases, otherwise only interim releases are gathered.
+	LTS       bool
+	getter    http.Getter
+	now       func() time.Time
+	signature *pgp.Signature
+}
+
+const homepageURL = "https://ubuntu.com/"
+
+const licenses = "LicenseRef-Ubuntu"
+
 var description string = `Ubuntu images for KubeVirt.
 <br />
 <br />
@@ -30,29 +87,42 @@ func (u *ubuntu) Metadata() *api.Metadata {
 		Name:                   "ubuntu",
 		Version:                u.Version,
 		Description:            description,
+		URL:                    homepageURL,
+		Licenses:               licenses,
 		ExampleUserDataPayload: u.UserData(&docs.UserData{}),
+		DefaultInstancetype:    "u1.medium",
+		DefaultPreference:      "ubuntu",
 	}
 }
 
-func (u *ubuntu) Inspect() (*api.ArtifactDetails, error) {
-	baseURL := fmt.Sprintf("https://cloud-images.ubuntu.com/releases/%v/release/", u.Version)
-	checksumURL := baseURL + "SHA256SUMS"
-	raw, err := u.getter.GetAll(checksumURL)
+func (u *ubuntu) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	streams, err := getStreams(ctx, u.getter, u.signature)
 	if err != nil {
-		return nil, fmt.Errorf("error downloading the ubuntu SHA256SUMS file: %v", err)
+		return nil, fmt.Errorf("error getting streams: %v", err)
 	}
-	checksums, err := hashsum.Parse(bytes.NewReader(raw), hashsum.ChecksumFormatGNU)
-	if err != nil {
-		return nil, fmt.Errorf("error reading the SHA256SUMS.txt file: %v", err)
+
+	product, exists := streams.Products[productName(u.Version, u.Arch)]
+	if !exists {
+		return nil, fmt.Errorf("no product information in the streams file for ubuntu:%q found", u.Version)
 	}
-	if checksum, exists := checksums[u.Variant]; exists {
+
+	serial := latestSerial(&product)
+	if serial == "" {
+		return nil, fmt.Errorf("no serial in the streams file for ubuntu:%q found", u.Version)
+	}
+
+	if item, exists := product.Versions[serial].Items[u.Variant]; exists {
 		return &api.ArtifactDetails{
-			SHA256Sum:   checksum,
-			DownloadURL: baseURL + u.Variant,
-			Compression: u.Compression,
+			SHA256Sum:            item.Sha256,
+			ChecksumURL:          streamsURL,
+			DownloadURL:          baseURL + item.Path,
+			Compression:          u.Compression,
+			AdditionalUniqueTags: []string{fmt.Sprintf("%s-%s", u.Version, serial), product.Release},
+			MovingTags:           []string{product.Release}, // The codename moves to newer serials
 		}, nil
 	}
-	return nil, fmt.Errorf("file %q does not exist in the SHA256SUMS file: %v", u.Variant, err)
+
+	return nil, fmt.Errorf("file %q does not exist in serial %q of ubuntu:%q", u.Variant, serial, u.Version)
 }
 
 func (u *ubuntu) VM(name, imgRef, userData string) *v1.VirtualMachine {
@@ -74,11 +144,103 @@ func (u *ubuntu) Tests() []api.ArtifactTest {
 	}
 }
 
+func (g *ubuntuGatherer) Gather(ctx context.Context) ([]api.Artifact, error) {
+	streams, err := getStreams(ctx, g.getter, g.signature)
+	if err != nil {
+		return nil, fmt.Errorf("error getting streams: %v", err)
+	}
+
+	versions := []string{}
+	for name := range streams.Products {
+		product := streams.Products[name]
+		if g.productMatches(&product) {
+			versions = append(versions, product.Version)
+		}
+	}
+
+	version.SortDescending(versions, version.CompareDebian)
+
+	artifacts := []api.Artifact{}
+	for _, version := range versions {
+		artifacts = append(artifacts, New(version))
+	}
+
+	return artifacts, nil
+}
+
+func getStreams(ctx context.Context, getter http.Getter, signature *pgp.Signature) (*Streams, error) {
+	raw, err := signature.Fetch(ctx, getter, streamsURL)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the ubuntu streams file: %v", err)
+	}
+
+	streams := &Streams{}
+	if err := json.Unmarshal(raw, streams); err != nil {
+		return nil, fmt.Errorf("error parsing the ubuntu streams file: %v", err)
+	}
+
+	return streams, nil
+}
+
+func productName(version, arch string) string {
+	return fmt.Sprintf("com.ubuntu.cloud:server:%s:%s", version, arch)
+}
+
+// latestSerial returns the newest build serial of a product. Serials are in the
+// YYYYMMDD or YYYYMMDD.N format, so they can be sorted lexically.
+func latestSerial(product *Product) string {
+	serial := ""
+	for s := range product.Versions {
+		if s > serial {
+			serial = s
+		}
+	}
+
+	return serial
+}
+
+func (g *ubuntuGatherer) productMatches(product *Product) bool {
+	if !product.Supported || product.Arch != g.Arch || isLTS(product) != g.LTS {
+		return false
+	}
+
+	eol, err := time.Parse("2006-01-02", product.SupportEOL)
+	return err == nil && g.now().Before(eol)
+}
+
+// isLTS returns true for long term support releases, their title is like "22.04 LTS".
+func isLTS(product *Product) bool {
+	return strings.HasSuffix(product.ReleaseTitle, " LTS")
+}
+
 func New(release string) *ubuntu {
 	return &ubuntu{
-		Version: release,
-		Arch:    "x86_64",
-		Variant: fmt.Sprintf("ubuntu-%v-server-cloudimg-amd64.img", release),
-		getter:  &http.HTTPGetter{},
+		Version:   release,
+		Arch:      "amd64",
+		Variant:   "disk1.img",
+		getter:    http.DefaultGetter,
+		signature: &pgp.Signature{Keyring: keyring},
+	}
+}
+
+// NewGatherer returns a gatherer of the supported LTS releases.
+func NewGatherer() *ubuntuGatherer {
+	return &ubuntuGatherer{
+		Arch:      "amd64",
+		LTS:       true,
+		getter:    http.DefaultGetter,
+		now:       time.Now,
+		signature: &pgp.Signature{Keyring: keyring},
+	}
+}
+
+// NewInterimGatherer returns a gatherer of the supported interim releases. They must
+// not be used for the docs and the latest tag, which are reserved for LTS releases.
+func NewInterimGatherer() *ubuntuGatherer {
+	return &ubuntuGatherer{
+		Arch:      "amd64",
+		getter:    http.DefaultGetter,
+		now:       time.Now,
+		signature: &pgp.Signature{Keyring: keyring},
 	}
 }
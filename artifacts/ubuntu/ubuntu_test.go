@@ -1,39 +1,138 @@
 package ubuntu
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to sign test data
+	"golang.org/x/crypto/openpgp"
 
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/pgp"
 	"kubevirt.io/containerdisks/testutil"
 )
 
 var _ = Describe("Ubuntu", func() {
-	DescribeTable("Inspect should be able to parse checksum files",
+	var (
+		signer      *openpgp.Entity
+		testKeyring *pgp.Keyring
+	)
+
+	BeforeEach(func() {
+		var err error
+		signer, testKeyring, err = testutil.NewSigner()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("Inspect should be able to parse streams files",
 		func(release, mockFile string, details *api.ArtifactDetails, metadata *api.Metadata) {
+			signedFile, err := testutil.ClearsignFile(signer, mockFile, GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
 			c := New(release)
-			c.getter = testutil.NewMockGetter(mockFile)
-			got, err := c.Inspect()
+			c.signature = &pgp.Signature{Keyring: testKeyring}
+			c.getter = testutil.NewMockGetter(signedFile)
+			got, err := c.Inspect(context.Background())
 			Expect(err).NotTo(HaveOccurred())
 			Expect(got).To(Equal(details))
 			Expect(c.Metadata()).To(Equal(metadata))
 		},
-		Entry("ubuntu:22.04", "22.04", "testdata/SHA256SUM",
+		Entry("ubuntu:22.04", "22.04", "testdata/streams.json",
 			&api.ArtifactDetails{
-				SHA256Sum:   "de5e632e17b8965f2baf4ea6d2b824788e154d9a65df4fd419ec4019898e15cd",
-				DownloadURL: "https://cloud-images.ubuntu.com/releases/22.04/release/ubuntu-22.04-server-cloudimg-amd64.img",
+				SHA256Sum:            "de5e632e17b8965f2baf4ea6d2b824788e154d9a65df4fd419ec4019898e15cd",
+				ChecksumURL:          "https://cloud-images.ubuntu.com/releases/streams/v1/com.ubuntu.cloud:released:download.sjson",
+				DownloadURL:          "https://cloud-images.ubuntu.com/releases/server/releases/jammy/release-20230302/ubuntu-22.04-server-cloudimg-amd64.img", //nolint:lll
+				AdditionalUniqueTags: []string{"22.04-20230302", "jammy"},
+				MovingTags:           []string{"jammy"},
 			},
 			&api.Metadata{
 				Name:                   "ubuntu",
 				Version:                "22.04",
 				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
+				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "ubuntu",
+			},
+		),
+		Entry("ubuntu:20.04", "20.04", "testdata/streams.json",
+			&api.ArtifactDetails{
+				SHA256Sum:            "f5328016f12aaf64e5634af7dc72727b9cdb0673f83194990e3ff70cb04023cb",
+				ChecksumURL:          "https://cloud-images.ubuntu.com/releases/streams/v1/com.ubuntu.cloud:released:download.sjson",
+				DownloadURL:          "https://cloud-images.ubuntu.com/releases/server/releases/focal/release-20230328/ubuntu-20.04-server-cloudimg-amd64.img", //nolint:lll
+				AdditionalUniqueTags: []string{"20.04-20230328", "focal"},
+				MovingTags:           []string{"focal"},
+			},
+			&api.Metadata{
+				Name:                   "ubuntu",
+				Version:                "20.04",
+				Description:            description,
+				URL:                    homepageURL,
+				Licenses:               licenses,
 				ExampleUserDataPayload: docs.CloudInit(&docs.UserData{}),
+				DefaultInstancetype:    "u1.medium",
+				DefaultPreference:      "ubuntu",
 			},
 		),
 	)
+
+	It("Inspect should fail for unknown releases", func() {
+		signedFile, err := testutil.ClearsignFile(signer, "testdata/streams.json", GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+
+		c := New("16.04")
+		c.signature = &pgp.Signature{Keyring: testKeyring}
+		c.getter = testutil.NewMockGetter(signedFile)
+		_, err = c.Inspect(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("no product information")))
+	})
+
+	It("Inspect should reject unsigned streams files", func() {
+		c := New("22.04")
+		Expect(c.signature.Keyring).To(Equal(keyring))
+		c.signature = &pgp.Signature{Keyring: testKeyring}
+		c.getter = testutil.NewMockGetter("testdata/streams.json")
+		_, err := c.Inspect(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("no clearsigned message found")))
+	})
+
+	DescribeTable("Gather should only return supported releases",
+		func(lts bool, now string, versions []string) {
+			signedFile, err := testutil.ClearsignFile(signer, "testdata/streams.json", GinkgoT().TempDir())
+			Expect(err).NotTo(HaveOccurred())
+
+			c := NewInterimGatherer()
+			if lts {
+				c = NewGatherer()
+			}
+			c.signature = &pgp.Signature{Keyring: testKeyring}
+			c.getter = testutil.NewMockGetter(signedFile)
+			c.now = func() time.Time {
+				t, parseErr := time.Parse("2006-01-02", now)
+				Expect(parseErr).NotTo(HaveOccurred())
+				return t
+			}
+
+			artifacts := []api.Artifact{}
+			for _, version := range versions {
+				artifacts = append(artifacts, New(version))
+			}
+
+			got, err := c.Gather(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(got).To(Equal(artifacts))
+		},
+		Entry("LTS before the bionic EOL", true, "2023-04-21", []string{"22.04", "20.04", "18.04"}),
+		Entry("LTS after the bionic EOL", true, "2023-08-01", []string{"22.04", "20.04"}),
+		Entry("interim before the kinetic EOL", false, "2023-04-21", []string{"23.04", "22.10"}),
+		Entry("interim after the kinetic EOL", false, "2023-08-01", []string{"23.04"}),
+		Entry("interim after the lunar EOL", false, "2024-02-01", []string{}),
+	)
 })
 
 func TestUbuntu(t *testing.T) {
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"regexp"
+
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+// TagAliasRule describes moving tags for a family of entries. Every alias is owned by the
+// newest entry of the family which maps to it, e.g. rhcos:4 is owned by rhcos:4.13.
+type TagAliasRule struct {
+	// Name is the image name of the entries the rule applies to.
+	Name string
+	// Versions restricts the rule to matching versions, e.g. to exclude rhcos:latest.
+	Versions *regexp.Regexp
+	// Alias returns the alias for a version, e.g. version.Major.
+	Alias func(v string) string
+	// Compare is used to find the newest entry of an alias.
+	Compare version.Comparator
+}
+
+var dottedVersionRex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)+$`)
+
+var tagAliasRules = []TagAliasRule{
+	{Name: "centos", Versions: dottedVersionRex, Alias: version.Major, Compare: version.CompareRPM},
+	{Name: "rhcos", Versions: dottedVersionRex, Alias: version.Major, Compare: version.CompareSemver},
+	{Name: "alpine", Versions: dottedVersionRex, Alias: version.Major, Compare: version.CompareSemver},
+	{Name: "freebsd", Versions: dottedVersionRex, Alias: version.Major, Compare: version.CompareSemver},
+	{Name: "opensuse", Versions: dottedVersionRex, Alias: version.Major, Compare: version.CompareSemver},
+}
+
+// applyTagAliasRules computes which entry of the registry owns each alias of rules.
+// Aliases which are equal to the version of an entry of the same family are skipped,
+// because they would overwrite its tag.
+func applyTagAliasRules(registry []Entry, rules []TagAliasRule) {
+	for _, rule := range rules {
+		versions := map[string]bool{}
+		for i := range registry {
+			if metadata := registry[i].Artifact.Metadata(); metadata.Name == rule.Name {
+				versions[metadata.Version] = true
+			}
+		}
+
+		owners := map[string]int{}
+		for i := range registry {
+			metadata := registry[i].Artifact.Metadata()
+			if metadata.Name != rule.Name || !rule.Versions.MatchString(metadata.Version) {
+				continue
+			}
+
+			alias := rule.Alias(metadata.Version)
+			if alias == "" || versions[alias] {
+				continue
+			}
+
+			owner, exists := owners[alias]
+			if !exists || rule.Compare(metadata.Version, registry[owner].Artifact.Metadata().Version) > 0 {
+				owners[alias] = i
+			}
+		}
+
+		for alias, owner := range owners {
+			registry[owner].Aliases = append(registry[owner].Aliases, alias)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"regexp"
+	"testing"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+func newEntry(name, release string) Entry {
+	return Entry{Artifact: generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: name, Version: release})}
+}
+
+var _ = ginkgo.Describe("Aliases", func() {
+	rules := []TagAliasRule{
+		{Name: "rhcos", Versions: dottedVersionRex, Alias: version.Major, Compare: version.CompareSemver},
+		{Name: "centos", Versions: regexp.MustCompile(`^8\.`), Alias: version.Major, Compare: version.CompareRPM},
+	}
+
+	ginkgo.DescribeTable("applyTagAliasRules should assign every alias to the newest entry",
+		func(registry []Entry, aliases [][]string) {
+			applyTagAliasRules(registry, rules)
+
+			Expect(registry).To(HaveLen(len(aliases)))
+			for i := range registry {
+				Expect(registry[i].Aliases).To(Equal(aliases[i]), registry[i].Artifact.Metadata().Describe())
+			}
+		},
+		ginkgo.Entry("with the newest entry in the middle",
+			[]Entry{newEntry("rhcos", "4.12"), newEntry("rhcos", "4.13"), newEntry("rhcos", "4.9")},
+			[][]string{nil, {"4"}, nil},
+		),
+		ginkgo.Entry("with versions which only differ numerically",
+			[]Entry{newEntry("rhcos", "4.9"), newEntry("rhcos", "4.10")},
+			[][]string{nil, {"4"}},
+		),
+		ginkgo.Entry("with one entry per alias",
+			[]Entry{newEntry("rhcos", "4.13"), newEntry("rhcos", "5.1")},
+			[][]string{{"4"}, {"5"}},
+		),
+		ginkgo.Entry("with equal versions the first entry wins",
+			[]Entry{newEntry("rhcos", "4.13"), newEntry("rhcos", "4.13")},
+			[][]string{{"4"}, nil},
+		),
+		ginkgo.Entry("with entries whose version does not match the rule",
+			[]Entry{newEntry("rhcos", "latest"), newEntry("centos", "7-2009"), newEntry("centos", "8.4")},
+			[][]string{nil, nil, {"8"}},
+		),
+		ginkgo.Entry("with entries of other images",
+			[]Entry{newEntry("fedora", "38"), newEntry("ubuntu", "22.04")},
+			[][]string{nil, nil},
+		),
+		ginkgo.Entry("with an alias which is the version of another entry",
+			[]Entry{newEntry("rhcos", "4"), newEntry("rhcos", "4.13")},
+			[][]string{nil, nil},
+		),
+	)
+
+	ginkgo.It("should not alias ubuntu versions", func() {
+		registry := []Entry{newEntry("ubuntu", "22.04"), newEntry("ubuntu", "23.04")}
+		applyTagAliasRules(registry, tagAliasRules)
+		Expect(registry[0].Aliases).To(BeEmpty())
+		Expect(registry[1].Aliases).To(BeEmpty())
+	})
+})
+
+func TestCommon(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Common Suite")
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/rest"
+	kvirtcli "kubevirt.io/client-go/kubecli"
+
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+// ConfigureNetwork sets up the HTTP client and cache which are shared by all network
+// access of medius. It must be called before any network access.
+func ConfigureNetwork(options *Options) error {
+	client, err := http.NewClient(&options.HTTPClientOptions)
+	if err != nil {
+		return err
+	}
+	http.SetDefaultClient(client)
+
+	if options.HTTPCacheDir != "" {
+		http.EnableDiskCache(options.HTTPCacheDir)
+	}
+
+	return configureKubevirtClient(&options.HTTPClientOptions)
+}
+
+// configureKubevirtClient applies the proxy, CA and timeout settings to all KubeVirt clients,
+// including the ones the tests of the artifacts create. The CAs are trusted in addition to
+// the CA of the kubeconfig.
+func configureKubevirtClient(opts *http.ClientOptions) error {
+	proxy, err := opts.ProxyFunc()
+	if err != nil {
+		return err
+	}
+	certs, err := opts.ReadCAFiles()
+	if err != nil {
+		return err
+	}
+
+	kvirtcli.RegisterRestConfigHook(func(config *rest.Config) {
+		config.Proxy = proxy
+		config.Dial = opts.DialContext()
+		if len(certs) == 0 || config.Insecure {
+			return
+		}
+
+		caData := config.CAData
+		if len(caData) == 0 && config.CAFile != "" {
+			var readErr error
+			if caData, readErr = os.ReadFile(config.CAFile); readErr != nil {
+				logrus.WithError(readErr).Warn("Failed to read the CA file of the kubeconfig, not adding the configured CAs")
+				return
+			}
+		}
+		if len(caData) > 0 {
+			config.CAData = append(append(append([]byte{}, caData...), '\n'), certs...)
+		}
+	})
+
+	return nil
+}
+
+// NewRepository returns a repository which uses the shared HTTP client.
+func NewRepository(options *Options) *repository.RepositoryImpl {
+	return &repository.RepositoryImpl{
+		Transport: http.DefaultClient().Transport,
+		UserAgent: options.HTTPClientOptions.UserAgent,
+	}
+}
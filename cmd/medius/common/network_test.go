@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	kvirtcli "kubevirt.io/client-go/kubecli"
+
+	medhttp "kubevirt.io/containerdisks/pkg/http"
+)
+
+var _ = ginkgo.Describe("Network", func() {
+	ginkgo.It("configureKubevirtClient should apply the proxy and CAs to KubeVirt clients", func() {
+		var proxied atomic.Int32
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+		}))
+		defer proxy.Close()
+
+		tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+		defer tlsServer.Close()
+		caFile := filepath.Join(ginkgo.GinkgoT().TempDir(), "ca.pem")
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsServer.Certificate().Raw})
+		Expect(os.WriteFile(caFile, caPEM, 0o600)).To(Succeed())
+		Expect(configureKubevirtClient(&medhttp.ClientOptions{Proxy: proxy.URL, CAFiles: []string{caFile}})).To(Succeed())
+
+		client, err := kvirtcli.GetKubevirtClientFromRESTConfig(&rest.Config{
+			Host:            "http://kubernetes.example.invalid",
+			TLSClientConfig: rest.TLSClientConfig{CAData: []byte("cluster CA\n")},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(client.Config().CAData)).To(Equal("cluster CA\n\n" + string(caPEM) + "\n"))
+
+		_, err = client.VirtualMachine("kubevirt").Get(context.Background(), "vm", &metav1.GetOptions{})
+		Expect(err).To(HaveOccurred())
+		Expect(proxied.Load()).To(BeNumerically(">", 0))
+	})
+})
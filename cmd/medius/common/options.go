@@ -1,9 +1,15 @@
 package common
 
+import "kubevirt.io/containerdisks/pkg/http"
+
 type Options struct {
 	AllowInsecureRegistry bool
+	AuditImagesOptions    AuditImageOptions
 	DryRun                bool
+	ExternalProviders     []string
 	Focus                 string
+	HTTPCacheDir          string
+	HTTPClientOptions     http.ClientOptions
 	ImagesOptions         ImagesOptions
 	PublishDocsOptions    PublishDocsOptions
 	PublishImagesOptions  PublishImageOptions
@@ -11,14 +17,22 @@ type Options struct {
 	VerifyImagesOptions   VerifyImageOptions
 }
 
+type AuditImageOptions struct {
+	NoFail       bool
+	Registry     string
+	SkipUpstream bool
+}
+
 type ImagesOptions struct {
 	ResultsFile string
 	Workers     int
 }
 
 type PromoteImageOptions struct {
-	SourceRegistry string
-	TargetRegistry string
+	SigningKey      string
+	SourceRegistry  string
+	TargetRegistry  string
+	VerificationKey string
 }
 
 type PublishDocsOptions struct {
@@ -27,10 +41,16 @@ type PublishDocsOptions struct {
 }
 
 type PublishImageOptions struct {
-	ForceBuild     bool
-	NoFail         bool
-	SourceRegistry string
-	TargetRegistry string
+	AcceptChecksumChanges []string
+	ChecksumHistoryFile   string
+	ForceBuild            bool
+	LayerCompression      string
+	Mirrors               map[string]string
+	NoFail                bool
+	SigningKey            string
+	SourceRegistry        string
+	TargetRegistry        string
+	VerifyReproducible    bool
 }
 
 type VerifyImageOptions struct {
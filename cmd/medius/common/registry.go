@@ -1,13 +1,21 @@
 package common
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"kubevirt.io/containerdisks/artifacts/alpine"
 	"kubevirt.io/containerdisks/artifacts/centos"
 	"kubevirt.io/containerdisks/artifacts/centosstream"
+	"kubevirt.io/containerdisks/artifacts/external"
 	"kubevirt.io/containerdisks/artifacts/fedora"
+	"kubevirt.io/containerdisks/artifacts/flatcar"
+	"kubevirt.io/containerdisks/artifacts/freebsd"
 	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/artifacts/opensuse"
 	"kubevirt.io/containerdisks/artifacts/rhcos"
 	"kubevirt.io/containerdisks/artifacts/rhcosprerelease"
 	"kubevirt.io/containerdisks/artifacts/ubuntu"
@@ -15,53 +23,30 @@ import (
 )
 
 type Entry struct {
-	Artifact           api.Artifact
-	UseForDocs         bool
-	UseForLatest       bool
+	Artifact     api.Artifact
+	UseForDocs   bool
+	UseForLatest bool
+	// Aliases are moving tags like 4 for rhcos:4.13, which are owned by the entry
+	// because it is the newest one of the alias. They are computed by the tag alias rules.
+	Aliases            []string
 	SkipWhenNotFocused bool
+	// Immutable marks entries whose version must always point to the same upstream image.
+	Immutable bool
 }
 
+const (
+	// GatherTimeout limits how long a single gatherer may take.
+	GatherTimeout = 2 * time.Minute
+	// InspectTimeout limits how long inspecting a single artifact may take.
+	InspectTimeout = 2 * time.Minute
+	// ProviderTimeout limits how long loading an external provider or a single call of it may take.
+	ProviderTimeout = 1 * time.Minute
+)
+
+// rhcosMinimumVersion is the oldest RHCOS minor version which is published.
+const rhcosMinimumVersion = "4.9"
+
 var staticRegistry = []Entry{
-	{
-		Artifact:   rhcos.New("4.9", true),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcos.New("4.10", true),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcos.New("4.11", true),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcos.New("4.12", true),
-		UseForDocs: true,
-	},
-	{
-		Artifact:   rhcos.New("latest", false),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcosprerelease.New("latest-4.9"),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcosprerelease.New("latest-4.10"),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcosprerelease.New("latest-4.11"),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcosprerelease.New("latest-4.12"),
-		UseForDocs: false,
-	},
-	{
-		Artifact:   rhcosprerelease.New("latest-4.13"),
-		UseForDocs: false,
-	},
 	{
 		Artifact:   rhcosprerelease.New("latest"),
 		UseForDocs: false,
@@ -69,31 +54,38 @@ var staticRegistry = []Entry{
 	{
 		Artifact:   centos.New("8.4"),
 		UseForDocs: false,
+		Immutable:  true,
 	},
 	{
 		Artifact:   centos.New("7-2009"),
 		UseForDocs: true,
+		Immutable:  true,
 	},
 	{
-		Artifact:   centosstream.New("9"),
+		Artifact:   opensuse.New("15.4"),
 		UseForDocs: true,
 	},
 	{
-		Artifact:   centosstream.New("8"),
+		Artifact:   opensuse.NewTumbleweed(),
 		UseForDocs: false,
 	},
 	{
-		Artifact:   ubuntu.New("22.04"),
+		Artifact:   flatcar.New("stable"),
 		UseForDocs: true,
 	},
 	{
-		Artifact:   ubuntu.New("20.04"),
+		Artifact:   flatcar.New("beta"),
 		UseForDocs: false,
 	},
 	{
-		Artifact:   ubuntu.New("18.04"),
+		Artifact:   flatcar.New("alpha"),
 		UseForDocs: false,
 	},
+	{
+		Artifact:   freebsd.New("13.2"),
+		UseForDocs: true,
+		Immutable:  true,
+	},
 	// for testing only
 	{
 		Artifact: generic.New(
@@ -108,34 +100,99 @@ var staticRegistry = []Entry{
 		),
 		SkipWhenNotFocused: true,
 		UseForDocs:         false,
+		Immutable:          true,
 	},
 }
 
-func gatherArtifacts(registry *[]Entry, gatherers []api.ArtifactsGatherer) {
-	for _, gatherer := range gatherers {
-		artifacts, err := gatherer.Gather()
+type gathererEntry struct {
+	Gatherer api.ArtifactsGatherer
+	// SkipDocsAndLatest prevents that the first gathered artifact is used for the docs and
+	// the latest tag, e.g. because it shares its image name with another gatherer.
+	SkipDocsAndLatest bool
+}
+
+func gather(ctx context.Context, gatherer api.ArtifactsGatherer) ([]api.Artifact, error) {
+	ctx, cancel := context.WithTimeout(ctx, GatherTimeout)
+	defer cancel()
+
+	return gatherer.Gather(ctx)
+}
+
+// Inspect returns the details of artifact and gives up after InspectTimeout.
+func Inspect(ctx context.Context, artifact api.Artifact) (*api.ArtifactDetails, error) {
+	ctx, cancel := context.WithTimeout(ctx, InspectTimeout)
+	defer cancel()
+
+	return artifact.Inspect(ctx)
+}
+
+func gatherArtifacts(ctx context.Context, registry *[]Entry, gatherers []gathererEntry) {
+	for _, g := range gatherers {
+		artifacts, err := gather(ctx, g.Gatherer)
 		if err != nil {
 			logrus.Warn("Failed to gather artifacts", err)
 		} else {
 			for i := range artifacts {
 				*registry = append(*registry, Entry{
 					Artifact:     artifacts[i],
-					UseForDocs:   i == 0,
-					UseForLatest: i == 0,
+					UseForDocs:   i == 0 && !g.SkipDocsAndLatest,
+					UseForLatest: i == 0 && !g.SkipDocsAndLatest,
 				})
 			}
 		}
 	}
 }
 
-func NewRegistry() []Entry {
+func loadExternalProvider(ctx context.Context, commandLine string) (api.Artifact, error) {
+	ctx, cancel := context.WithTimeout(ctx, ProviderTimeout)
+	defer cancel()
+
+	artifact, err := external.New(ctx, commandLine)
+	if err != nil {
+		return nil, err
+	}
+	artifact.Timeout = ProviderTimeout
+
+	return artifact, nil
+}
+
+func loadExternalProviders(ctx context.Context, registry *[]Entry, commandLines []string) error {
+	for _, commandLine := range commandLines {
+		artifact, err := loadExternalProvider(ctx, commandLine)
+		if err != nil {
+			return fmt.Errorf("error loading the external provider %q: %v", commandLine, err)
+		}
+		*registry = append(*registry, Entry{
+			Artifact: artifact,
+		})
+	}
+
+	return nil
+}
+
+// NewRegistry returns all static, external and gathered artifacts. Upstream requests
+// of gatherers and external providers are stopped when ctx is done. Failing external
+// providers are an error, as their artifacts would silently be missing otherwise.
+func NewRegistry(ctx context.Context, options *Options) ([]Entry, error) {
 	registry := make([]Entry, len(staticRegistry))
 	copy(registry, staticRegistry)
+	if err := loadExternalProviders(ctx, &registry, options.ExternalProviders); err != nil {
+		return nil, err
+	}
 
-	gatherers := []api.ArtifactsGatherer{fedora.NewGatherer()}
-	gatherArtifacts(&registry, gatherers)
+	gatherers := []gathererEntry{
+		{Gatherer: fedora.NewGatherer()},
+		{Gatherer: centosstream.NewGatherer()},
+		{Gatherer: ubuntu.NewGatherer()},
+		{Gatherer: ubuntu.NewInterimGatherer(), SkipDocsAndLatest: true},
+		{Gatherer: alpine.NewGatherer()},
+		{Gatherer: rhcos.NewGatherer(rhcosMinimumVersion)},
+		{Gatherer: rhcosprerelease.NewGatherer(rhcosMinimumVersion), SkipDocsAndLatest: true},
+	}
+	gatherArtifacts(ctx, &registry, gatherers)
+	applyTagAliasRules(registry, tagAliasRules)
 
-	return registry
+	return registry, nil
 }
 
 func ShouldSkip(focus string, entry *Entry) bool {
@@ -0,0 +1,77 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+const upstreamURL = "https://upstream.example.org/releases.json"
+
+// upstreamArtifact gathers and inspects by requesting upstreamURL like the real artifacts.
+type upstreamArtifact struct {
+	api.Artifact
+	getter http.Getter
+}
+
+func newUpstreamArtifact(getter http.Getter) *upstreamArtifact {
+	return &upstreamArtifact{
+		Artifact: generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "upstream", Version: "1.0"}),
+		getter:   getter,
+	}
+}
+
+func (u *upstreamArtifact) Gather(ctx context.Context) ([]api.Artifact, error) {
+	if _, err := u.getter.GetAllWithContext(ctx, upstreamURL); err != nil {
+		return nil, fmt.Errorf("error getting the releases: %w", err)
+	}
+
+	return []api.Artifact{u}, nil
+}
+
+func (u *upstreamArtifact) Inspect(ctx context.Context) (*api.ArtifactDetails, error) {
+	if _, err := u.getter.GetAllWithContext(ctx, upstreamURL); err != nil {
+		return nil, fmt.Errorf("error getting the release: %w", err)
+	}
+
+	return u.Artifact.Inspect(ctx)
+}
+
+var _ = ginkgo.Describe("Registry", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	ginkgo.BeforeEach(func() {
+		ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	})
+
+	ginkgo.AfterEach(func() {
+		cancel()
+	})
+
+	ginkgo.It("gather should return the deadline error of an upstream which never responds", func() {
+		artifacts, err := gather(ctx, newUpstreamArtifact(testutil.NewBlockingGetter()))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(artifacts).To(BeEmpty())
+	})
+
+	ginkgo.It("gatherArtifacts should skip a gatherer whose upstream never responds", func() {
+		registry := []Entry{}
+		gatherArtifacts(ctx, &registry, []gathererEntry{{Gatherer: newUpstreamArtifact(testutil.NewBlockingGetter())}})
+		Expect(registry).To(BeEmpty())
+	})
+
+	ginkgo.It("Inspect should return the deadline error of an upstream which never responds", func() {
+		details, err := Inspect(ctx, newUpstreamArtifact(testutil.NewBlockingGetter()))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(details).To(BeNil())
+	})
+})
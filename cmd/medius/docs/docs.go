@@ -13,6 +13,7 @@ import (
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/docs"
+	"kubevirt.io/containerdisks/pkg/http"
 	"kubevirt.io/containerdisks/pkg/quay"
 	"sigs.k8s.io/yaml"
 )
@@ -26,7 +27,7 @@ func NewPublishDocsCommand(options *common.Options) *cobra.Command {
 		Use:   "publish",
 		Short: "Synchronize container disk descriptions with quay.io",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return run(options)
+			return run(cmd.Context(), options)
 		},
 	}
 	publishCmd.Flags().StringVar(&options.PublishDocsOptions.Registry, "registry",
@@ -42,7 +43,7 @@ func NewPublishDocsCommand(options *common.Options) *cobra.Command {
 	return publishCmd
 }
 
-func run(options *common.Options) error {
+func run(ctx context.Context, options *common.Options) error {
 	success := true
 	focusMatched := false
 
@@ -51,8 +52,11 @@ func run(options *common.Options) error {
 		return err
 	}
 
-	client := quay.NewQuayClient(options.PublishDocsOptions.TokenFile, quayOrg)
-	registry := common.NewRegistry()
+	client := quay.NewQuayClient(options.PublishDocsOptions.TokenFile, quayOrg, http.DefaultClient())
+	registry, err := common.NewRegistry(ctx, options)
+	if err != nil {
+		return err
+	}
 	for i, p := range registry {
 		if common.ShouldSkip(options.Focus, &registry[i]) || !p.UseForDocs {
 			continue
@@ -62,7 +66,7 @@ func run(options *common.Options) error {
 		log := common.Logger(p.Artifact)
 		name := p.Artifact.Metadata().Name
 
-		description, err := createDescription(p.Artifact, options.PublishDocsOptions.Registry)
+		description, err := createDescription(ctx, p.Artifact, options.PublishDocsOptions.Registry)
 		if err != nil {
 			success = false
 			log.Errorf("error marshaling example for %q: %v", name, err)
@@ -71,7 +75,7 @@ func run(options *common.Options) error {
 
 		log.Info("Updating description on quay.io")
 		if !options.DryRun {
-			if err := client.Update(context.Background(), name, description); err != nil {
+			if err := client.Update(ctx, name, description); err != nil {
 				success = false
 				log.Errorf("error marshaling example for for %q: %v", name, err)
 			}
@@ -101,13 +105,19 @@ func getQuayOrg(registry string) (string, error) {
 	return elements[1], nil
 }
 
-func createDescription(artifact api.Artifact, registry string) (string, error) {
+func createDescription(ctx context.Context, artifact api.Artifact, registry string) (string, error) {
 	metadata := artifact.Metadata()
-	vm := artifact.VM(
+	vm, err := api.CreateVM(ctx, artifact,
 		metadata.Name,
 		path.Join(registry, artifact.Metadata().Describe()),
 		metadata.ExampleUserDataPayload,
 	)
+	if err != nil {
+		return "", fmt.Errorf("error creating the VM of %q: %v", metadata.Describe(), err)
+	}
+	if vm == nil {
+		return "", fmt.Errorf("artifact %q did not provide a VM", metadata.Describe())
+	}
 
 	example, err := yaml.Marshal(&vm)
 	if err != nil {
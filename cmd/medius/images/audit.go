@@ -0,0 +1,326 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/repository"
+)
+
+type auditContainerDisks struct {
+	Ctx     context.Context
+	Log     *logrus.Entry
+	Options *common.Options
+	Repo    repository.Repository
+	Tags    *auditedTags
+}
+
+// auditedTags tracks the repositories and tags audited by the workers, so that the remaining
+// published tags of the repositories can be audited afterwards. It is safe for concurrent use.
+type auditedTags struct {
+	lock sync.Mutex
+	tags map[string]map[string]bool
+}
+
+func newAuditedTags() *auditedTags {
+	return &auditedTags{tags: map[string]map[string]bool{}}
+}
+
+func (t *auditedTags) add(name, tag string) {
+	if t == nil {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tags[name] == nil {
+		t.tags[name] = map[string]bool{}
+	}
+	t.tags[name][tag] = true
+}
+
+func (t *auditedTags) contains(name, tag string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.tags[name][tag]
+}
+
+// repositories returns the sorted names of all repositories with audited tags.
+func (t *auditedTags) repositories() []string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	names := make([]string, 0, len(t.tags))
+	for name := range t.tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func NewAuditImagesCommand(options *common.Options) *cobra.Command {
+	options.AuditImagesOptions = common.AuditImageOptions{
+		Registry: "quay.io/containerdisks",
+	}
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Check that published containerdisks still contain what was built",
+		Run: func(cmd *cobra.Command, args []string) {
+			tags := newAuditedTags()
+			focusMatched, _, workerErr := spawnWorkers(cmd.Context(), options, func(e *common.Entry) (*api.ArtifactResult, error) {
+				a := auditContainerDisks{
+					Ctx:     cmd.Context(),
+					Log:     common.Logger(e.Artifact),
+					Options: options,
+					Repo:    common.NewRepository(options),
+					Tags:    tags,
+				}
+
+				return nil, a.Do(e)
+			})
+
+			if !focusMatched {
+				logrus.Fatalf("no artifact was processed, focus '%s' did not match", options.Focus)
+			}
+
+			a := auditContainerDisks{
+				Ctx:     cmd.Context(),
+				Log:     logrus.NewEntry(logrus.StandardLogger()),
+				Options: options,
+				Repo:    common.NewRepository(options),
+				Tags:    tags,
+			}
+			if err := a.AuditRemainingTags(); err != nil {
+				logrus.Error(err)
+				if workerErr == nil {
+					workerErr = err
+				}
+			}
+
+			if workerErr != nil {
+				if options.AuditImagesOptions.NoFail {
+					logrus.Warn(workerErr)
+				} else {
+					logrus.Fatal(workerErr)
+				}
+			}
+		},
+	}
+	auditCmd.Flags().BoolVar(&options.AuditImagesOptions.NoFail, "no-fail",
+		options.AuditImagesOptions.NoFail, "Return success even if drift was found")
+	auditCmd.Flags().StringVar(&options.AuditImagesOptions.Registry, "registry",
+		options.AuditImagesOptions.Registry, "Registry that contains the containerdisks to audit")
+	auditCmd.Flags().BoolVar(&options.AuditImagesOptions.SkipUpstream, "skip-upstream",
+		options.AuditImagesOptions.SkipUpstream, "Do not compare the containerdisks with the upstream checksums")
+
+	return auditCmd
+}
+
+// auditTag is a published tag of an entry and what is known about it upstream.
+type auditTag struct {
+	Tag string
+	// Upstream is set if the upstream currently serves the artifact of the tag.
+	Upstream bool
+	// Immutable is set if the tag must always contain the same upstream image.
+	Immutable bool
+}
+
+// Do audits all published tags of the entry and returns an error listing all drift.
+func (a *auditContainerDisks) Do(entry *common.Entry) error {
+	metadata := entry.Artifact.Metadata()
+
+	var artifactInfo *api.ArtifactDetails
+	if !a.Options.AuditImagesOptions.SkipUpstream {
+		var err error
+		if artifactInfo, err = common.Inspect(a.Ctx, entry.Artifact); err != nil {
+			a.Log.WithError(err).Warn("Upstream artifact is not available, skipping the upstream comparison")
+			artifactInfo = nil
+		}
+	}
+
+	audited := map[string]*build.AuditResult{}
+	var drift []string
+	for _, tag := range auditTags(entry, artifactInfo) {
+		a.Tags.add(metadata.Name, tag.Tag)
+		tagDrift, err := a.auditTag(metadata.Name, tag, artifactInfo, audited)
+		if err != nil {
+			return err
+		}
+		drift = append(drift, tagDrift...)
+	}
+
+	if len(drift) > 0 {
+		return fmt.Errorf("containerdisk %q drifted: %s", metadata.Describe(), strings.Join(drift, "; "))
+	}
+	a.Log.Info("No drift found")
+
+	return nil
+}
+
+// AuditRemainingTags audits all published tags of the audited repositories which were not
+// audited as part of an entry, like the timestamp tags and the unique tags of older builds.
+// Their upstream artifact is unknown, so only their layout and disk image are checked.
+// Signature and attestation tags are skipped.
+func (a *auditContainerDisks) AuditRemainingTags() error {
+	var drift []string
+	for _, name := range a.Tags.repositories() {
+		repo := path.Join(a.Options.AuditImagesOptions.Registry, name)
+		tags, err := a.Repo.ListTags(a.Ctx, repo, a.Options.AllowInsecureRegistry)
+		if err != nil {
+			return fmt.Errorf("error listing the tags of %q: %v", repo, err)
+		}
+
+		audited := map[string]*build.AuditResult{}
+		for _, tag := range tags {
+			if strings.HasSuffix(tag, ".sig") || strings.HasPrefix(tag, "sha256-") || a.Tags.contains(name, tag) {
+				continue
+			}
+			var tagDrift []string
+			if tagDrift, err = a.auditTag(name, auditTag{Tag: tag}, nil, audited); err != nil {
+				return err
+			}
+			for _, d := range tagDrift {
+				drift = append(drift, fmt.Sprintf("%s:%s", name, d))
+			}
+		}
+	}
+
+	if len(drift) > 0 {
+		return fmt.Errorf("containerdisks drifted: %s", strings.Join(drift, "; "))
+	}
+
+	return nil
+}
+
+func auditTags(entry *common.Entry, artifactInfo *api.ArtifactDetails) []auditTag {
+	upstream := artifactInfo != nil
+	tags := []auditTag{{Tag: entry.Artifact.Metadata().Version, Upstream: upstream, Immutable: entry.Immutable}}
+	for _, alias := range entry.Aliases {
+		tags = append(tags, auditTag{Tag: alias, Upstream: upstream})
+	}
+	if entry.UseForLatest {
+		tags = append(tags, auditTag{Tag: "latest", Upstream: upstream})
+	}
+
+	if artifactInfo != nil {
+		movingTags := map[string]bool{}
+		for _, tag := range artifactInfo.MovingTags {
+			movingTags[tag] = true
+		}
+		for _, tag := range artifactInfo.AdditionalUniqueTags {
+			if tag == "" {
+				continue
+			}
+			tags = append(tags, auditTag{Tag: tag, Upstream: true, Immutable: entry.Immutable || !movingTags[tag]})
+		}
+	}
+
+	return tags
+}
+
+// auditTag checks the layout and disk image of a single tag. The layers of identical
+// images are only read once.
+func (a *auditContainerDisks) auditTag(name string, tag auditTag, artifactInfo *api.ArtifactDetails,
+	audited map[string]*build.AuditResult) ([]string, error) {
+	imgRef := fmt.Sprintf("%s:%s", path.Join(a.Options.AuditImagesOptions.Registry, name), tag.Tag)
+	img, err := a.Repo.PullImage(a.Ctx, imgRef, a.Options.AllowInsecureRegistry)
+	if repository.IsImageNotFoundError(err) {
+		a.Log.Infof("%s is not published, skipping", imgRef)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error pulling %q: %v", imgRef, err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return nil, err
+	}
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the config of %q: %v", imgRef, err)
+	}
+	labels := config.Config.Labels
+
+	result, exists := audited[digest.String()]
+	if !exists {
+		a.Log.Infof("Auditing %s (%s) ...", imgRef, digest)
+		if result, err = build.Audit(img); err != nil {
+			return nil, fmt.Errorf("error auditing %q: %v", imgRef, err)
+		}
+		audited[digest.String()] = result
+	}
+
+	drift := append([]string(nil), result.Drift...)
+	diskDrift, verified := checkDiskChecksum(labels, result, artifactInfo)
+	if !verified {
+		a.Log.Warnf("The disk image checksum of %s can't be verified without the %s label", imgRef, build.LabelDiskShaSum)
+	}
+	drift = append(drift, diskDrift...)
+
+	if tag.Upstream {
+		checksumLabel, checksum := checksumLabelAndValue(artifactInfo)
+		if labels[checksumLabel] != checksum {
+			if tag.Immutable {
+				drift = append(drift, fmt.Sprintf("upstream checksum %q differs from label %s=%q",
+					checksum, checksumLabel, labels[checksumLabel]))
+			} else {
+				a.Log.Warnf("%s is outdated, the upstream checksum is %q", imgRef, checksum)
+			}
+		}
+	}
+
+	for i := range drift {
+		drift[i] = fmt.Sprintf("%s: %s", tag.Tag, drift[i])
+	}
+
+	return drift, nil
+}
+
+// checkDiskChecksum compares the checksum of the disk image with the labels. Images pushed
+// before the disk checksum label was introduced can only be checked against the
+// checksum labels if the upstream artifact is not compressed.
+func checkDiskChecksum(labels map[string]string, result *build.AuditResult,
+	artifactInfo *api.ArtifactDetails) (drift []string, verified bool) {
+	if result.DiskSHA256Sum == "" {
+		return nil, true
+	}
+
+	expected := map[string]string{}
+	if checksum, exists := labels[build.LabelDiskShaSum]; exists {
+		expected[build.LabelDiskShaSum] = checksum
+	} else if artifactInfo != nil && artifactInfo.Compression == "" {
+		expected[build.LabelShaSum] = labels[build.LabelShaSum]
+		if checksum, exists := labels[build.LabelSha512Sum]; exists {
+			expected[build.LabelSha512Sum] = checksum
+		}
+	}
+
+	for _, label := range []string{build.LabelDiskShaSum, build.LabelShaSum, build.LabelSha512Sum} {
+		checksum, exists := expected[label]
+		if !exists {
+			continue
+		}
+		actual := result.DiskSHA256Sum
+		if label == build.LabelSha512Sum {
+			actual = result.DiskSHA512Sum
+		}
+		if checksum != actual {
+			drift = append(drift, fmt.Sprintf("disk image checksum %q differs from label %s=%q", actual, label, checksum))
+		}
+	}
+
+	return drift, len(expected) > 0
+}
@@ -0,0 +1,124 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
+)
+
+var _ = Describe("Audit", func() {
+	const diskContent = "disk"
+
+	var (
+		host         string
+		checksum     string
+		artifactInfo *api.ArtifactDetails
+		a            *auditContainerDisks
+		entry        *common.Entry
+	)
+
+	pushContainerDisk := func(content, tag string) {
+		file := filepath.Join(GinkgoT().TempDir(), "disk.img")
+		Expect(os.WriteFile(file, []byte(content), 0o600)).To(Succeed())
+		img, err := build.ContainerDisk(file, checksum, time.Unix(0, 0), build.WithLabel(build.LabelDiskShaSum, checksum))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(mustTag(host+"/containerdisks/fedora:"+tag), img)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+		DeferCleanup(server.Close)
+		host = strings.TrimPrefix(server.URL, "http://")
+
+		sum := sha256.Sum256([]byte(diskContent))
+		checksum = hex.EncodeToString(sum[:])
+		artifactInfo = &api.ArtifactDetails{
+			SHA256Sum:            checksum,
+			AdditionalUniqueTags: []string{"38-1.6"},
+		}
+		entry = &common.Entry{
+			Artifact: generic.New(artifactInfo, &api.Metadata{Name: "fedora", Version: "38"}),
+		}
+		a = &auditContainerDisks{
+			Ctx: context.Background(),
+			Log: logrus.NewEntry(logrus.StandardLogger()),
+			Options: &common.Options{
+				AuditImagesOptions: common.AuditImageOptions{Registry: host + "/containerdisks"},
+			},
+			Repo: common.NewRepository(&common.Options{}),
+			Tags: newAuditedTags(),
+		}
+
+		pushContainerDisk(diskContent, "38")
+		pushContainerDisk(diskContent, "38-1.6")
+	})
+
+	It("should not find drift in untouched containerdisks", func() {
+		Expect(a.Do(entry)).To(Succeed())
+	})
+
+	It("should skip tags which are not published", func() {
+		entry.Aliases = []string{"rawhide"}
+		Expect(a.Do(entry)).To(Succeed())
+	})
+
+	It("should find a replaced disk image", func() {
+		pushContainerDisk("tampered", "38-1.6")
+
+		err := a.Do(entry)
+		Expect(err).To(MatchError(ContainSubstring("38-1.6: disk image checksum")))
+		Expect(err.Error()).NotTo(ContainSubstring("38: "))
+	})
+
+	It("should only report changed upstream checksums of immutable tags as drift", func() {
+		artifactInfo.SHA256Sum = strings.Repeat("0", 64)
+
+		err := a.Do(entry)
+		Expect(err).To(MatchError(ContainSubstring("38-1.6: upstream checksum")))
+		Expect(err.Error()).NotTo(ContainSubstring("38: "))
+	})
+
+	It("should audit the remaining published tags of the repository", func() {
+		pushContainerDisk(diskContent, "38-2304181200")
+		pushContainerDisk("tampered", "37-2304181200")
+		pushContainerDisk("tampered", "sha256-0000000000000000000000000000000000000000000000000000000000000000.sig")
+		Expect(a.Do(entry)).To(Succeed())
+
+		err := a.AuditRemainingTags()
+		Expect(err).To(MatchError(ContainSubstring("fedora:37-2304181200: disk image checksum")))
+		Expect(err.Error()).NotTo(ContainSubstring("38-2304181200"))
+		Expect(err.Error()).NotTo(ContainSubstring(".sig"))
+	})
+
+	It("should not audit the tags of an entry twice", func() {
+		pushContainerDisk("tampered", "38-1.6")
+		Expect(a.Do(entry)).NotTo(Succeed())
+
+		Expect(a.AuditRemainingTags()).To(Succeed())
+	})
+
+	It("should not compare with upstream when skipped", func() {
+		artifactInfo.SHA256Sum = strings.Repeat("0", 64)
+		a.Options.AuditImagesOptions.SkipUpstream = true
+
+		Expect(a.Do(entry)).To(Succeed())
+	})
+})
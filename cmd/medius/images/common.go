@@ -25,7 +25,10 @@ type workerResult struct {
 
 func spawnWorkers(ctx context.Context, o *common.Options,
 	fn func(*common.Entry) (*api.ArtifactResult, error)) (matched bool, resultsChan chan workerResult, err error) {
-	registry := common.NewRegistry()
+	registry, err := common.NewRegistry(ctx, o)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 	count := len(registry)
 	errChan := make(chan error, count)
 	jobChan := make(chan *common.Entry, count)
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"path"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/provenance"
 	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/signing"
 )
 
 func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
@@ -26,6 +29,10 @@ func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 			if err != nil {
 				logrus.Fatal(err)
 			}
+			keys, err := loadPromoteKeys(&options.PromoteImageOptions)
+			if err != nil {
+				logrus.Fatal(err)
+			}
 
 			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, func(e *common.Entry) (*api.ArtifactResult, error) {
 				description := e.Artifact.Metadata().Describe()
@@ -41,7 +48,7 @@ func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 				}
 
 				errString := ""
-				err := promoteArtifact(cmd.Context(), e.Artifact, r.Tags, options)
+				err := promoteArtifact(cmd.Context(), e.Artifact, &r, options, keys)
 				if err != nil {
 					errString = err.Error()
 				}
@@ -50,6 +57,7 @@ func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 					Tags:  r.Tags,
 					Stage: StagePromote,
 					Err:   errString,
+					Tests: r.Tests,
 				}, err
 			})
 
@@ -76,17 +84,52 @@ func NewPromoteImagesCommand(options *common.Options) *cobra.Command {
 		options.PromoteImageOptions.SourceRegistry, "Registry to pull images from")
 	promoteCmd.Flags().StringVar(&options.PromoteImageOptions.TargetRegistry, "target-registry",
 		options.PromoteImageOptions.TargetRegistry, "Registry to promote images to")
+	promoteCmd.Flags().StringVar(&options.PromoteImageOptions.SigningKey, "signing-key",
+		options.PromoteImageOptions.SigningKey, "PEM encoded ECDSA private key to sign promoted containerdisks with")
+	promoteCmd.Flags().StringVar(&options.PromoteImageOptions.VerificationKey, "verification-key",
+		options.PromoteImageOptions.VerificationKey, "PEM encoded ECDSA public key which must have signed promoted containerdisks")
 
 	err := promoteCmd.MarkFlagRequired("source-registry")
 	if err != nil {
 		logrus.Fatal(err)
 	}
+	err = promoteCmd.MarkFlagRequired("verification-key")
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
 	return promoteCmd
 }
 
-func promoteArtifact(ctx context.Context, artifact api.Artifact, tags []string, options *common.Options) error {
+type promoteKeys struct {
+	signer   *signing.Signer
+	verifier *signing.Verifier
+}
+
+func loadPromoteKeys(options *common.PromoteImageOptions) (*promoteKeys, error) {
+	keys := &promoteKeys{}
+	var err error
+	if options.SigningKey != "" {
+		if keys.signer, err = signing.LoadSigner(options.SigningKey); err != nil {
+			return nil, err
+		}
+	}
+	if options.VerificationKey == "" {
+		return nil, errors.New("a verification key is required to promote containerdisks")
+	}
+	if keys.verifier, err = signing.LoadVerifier(options.VerificationKey); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// promoteArtifact copies the verified containerdisk of result to the target registry.
+// Images whose signature from the push stage does not verify are never copied.
+func promoteArtifact(ctx context.Context, artifact api.Artifact, result *api.ArtifactResult, options *common.Options,
+	keys *promoteKeys) error {
 	log := common.Logger(artifact)
+	tags := result.Tags
 
 	if len(tags) == 0 {
 		err := errors.New("no containerdisks to promote")
@@ -94,18 +137,70 @@ func promoteArtifact(ctx context.Context, artifact api.Artifact, tags []string,
 		return err
 	}
 
-	repo := repository.RepositoryImpl{}
+	repo := common.NewRepository(options)
 	srcRef := path.Join(options.PromoteImageOptions.SourceRegistry, tags[0])
+	dstRef := path.Join(options.PromoteImageOptions.TargetRegistry, tags[0])
+
+	digest, err := repo.ImageDigest(ctx, srcRef, options.AllowInsecureRegistry)
+	if err != nil {
+		log.WithError(err).Error("Failed to get the image digest")
+		return err
+	}
+	if keys.verifier == nil {
+		err = errors.New("no verification key to check the signature of the push stage")
+		log.WithError(err).Error("Refusing to promote an unverified image")
+		return err
+	}
+	log.Infof("Verifying the signature of %s@%s", srcRef, digest)
+	if err = repo.VerifyImage(ctx, keys.verifier, srcRef, digest, options.AllowInsecureRegistry); err != nil {
+		log.WithError(err).Error("Refusing to promote an image without a valid signature")
+		return err
+	}
+	// Copy the resolved digest, the tag could have been moved in the meantime
+	srcDigestRef := fmt.Sprintf("%s@%s", path.Join(options.PromoteImageOptions.SourceRegistry, artifact.Metadata().Name), digest)
+
+	// Like push, only the first tag is copied before the image is signed and its provenance
+	// and SBOM are promoted, so that the moving tags never point to an incomplete image.
+	if err = copyTags(ctx, repo, artifact, srcDigestRef, tags[:1], options); err != nil {
+		return err
+	}
+
+	if options.DryRun {
+		log.Infof("Dry run enabled, not signing %s@%s and not promoting its provenance and SBOM", dstRef, digest)
+	} else {
+		if keys.signer != nil {
+			log.Infof("Signing %s@%s", dstRef, digest)
+			if err = repo.SignImage(ctx, keys.signer, dstRef, digest, options.AllowInsecureRegistry); err != nil {
+				log.WithError(err).Error("Failed to sign image")
+				return err
+			}
+		}
+
+		if err = promoteProvenance(ctx, repo, artifact, result, srcRef, dstRef, digest, options); err != nil {
+			return err
+		}
+		if err = promoteSBOM(ctx, repo, artifact, srcRef, dstRef, digest, options); err != nil {
+			return err
+		}
+	}
+
+	return copyTags(ctx, repo, artifact, srcDigestRef, tags[1:], options)
+}
+
+// copyTags copies srcDigestRef to every tag in the target registry.
+func copyTags(ctx context.Context, repo repository.Repository, artifact api.Artifact, srcDigestRef string, tags []string,
+	options *common.Options) error {
+	log := common.Logger(artifact)
 	for _, tag := range tags {
-		dstRef := path.Join(options.PromoteImageOptions.TargetRegistry, tag)
+		tagRef := path.Join(options.PromoteImageOptions.TargetRegistry, tag)
 		if !options.DryRun {
-			log.Infof("Copying %s -> %s", srcRef, dstRef)
-			if err := repo.CopyImage(ctx, srcRef, dstRef, options.AllowInsecureRegistry); err != nil {
+			log.Infof("Copying %s -> %s", srcDigestRef, tagRef)
+			if err := repo.CopyImage(ctx, srcDigestRef, tagRef, options.AllowInsecureRegistry); err != nil {
 				log.WithError(err).Error("Failed to copy image")
 				return err
 			}
 		} else {
-			log.Infof("Dry run enabled, not copying %s -> %s", srcRef, dstRef)
+			log.Infof("Dry run enabled, not copying %s -> %s", srcDigestRef, tagRef)
 		}
 
 		if errors.Is(ctx.Err(), context.Canceled) {
@@ -115,3 +210,53 @@ func promoteArtifact(ctx context.Context, artifact api.Artifact, tags []string,
 
 	return nil
 }
+
+// promoteProvenance attaches the provenance of the source image to the target image
+// and records the results of the verify stage.
+func promoteProvenance(ctx context.Context, repo repository.Repository, artifact api.Artifact, result *api.ArtifactResult,
+	srcRef, dstRef string, digest v1.Hash, options *common.Options) error {
+	log := common.Logger(artifact)
+	statement, err := repo.FetchProvenance(ctx, srcRef, digest, options.AllowInsecureRegistry)
+	if errors.Is(err, provenance.ErrNotFound) {
+		log.Warnf("%s@%s has no provenance, not promoting it", srcRef, digest)
+		return nil
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to read the provenance")
+		return err
+	}
+
+	statement = statement.WithSubject(path.Join(options.PromoteImageOptions.TargetRegistry, artifact.Metadata().Name))
+	statement.SetVerification(StageVerify, api.TestPassed, result.Tests)
+	log.Infof("Attaching the provenance to %s@%s", dstRef, digest)
+	if err = repo.AttachProvenance(ctx, statement, dstRef, digest, options.AllowInsecureRegistry); err != nil {
+		log.WithError(err).Error("Failed to attach the provenance")
+		return err
+	}
+
+	return nil
+}
+
+// promoteSBOM attaches the SBOM of the source image to the target image.
+func promoteSBOM(ctx context.Context, repo repository.Repository, artifact api.Artifact, srcRef, dstRef string, digest v1.Hash,
+	options *common.Options) error {
+	log := common.Logger(artifact)
+	sbom, err := repo.FetchSBOM(ctx, srcRef, digest, options.AllowInsecureRegistry)
+	if errors.Is(err, provenance.ErrNotFound) {
+		log.Warnf("%s@%s has no SBOM, not promoting it", srcRef, digest)
+		return nil
+	}
+	if err != nil {
+		log.WithError(err).Error("Failed to read the SBOM")
+		return err
+	}
+
+	sbom = sbom.WithSubject(path.Join(options.PromoteImageOptions.TargetRegistry, artifact.Metadata().Name))
+	log.Infof("Attaching the SBOM to %s@%s", dstRef, digest)
+	if err = repo.AttachSBOM(ctx, sbom, dstRef, digest, options.AllowInsecureRegistry); err != nil {
+		log.WithError(err).Error("Failed to attach the SBOM")
+		return err
+	}
+
+	return nil
+}
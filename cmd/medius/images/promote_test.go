@@ -0,0 +1,200 @@
+package images
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/provenance"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Promote", func() {
+	var (
+		server   *httptest.Server
+		options  *common.Options
+		artifact api.Artifact
+		digest   v1.Hash
+		keys     *promoteKeys
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+		host := strings.TrimPrefix(server.URL, "http://")
+		options = &common.Options{
+			PromoteImageOptions: common.PromoteImageOptions{
+				SourceRegistry: host + "/staging",
+				TargetRegistry: host + "/containerdisks",
+			},
+		}
+		artifact = generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "fedora", Version: "38"})
+
+		img, err := random.Image(1024, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(mustTag(host+"/staging/fedora:38"), img)).To(Succeed())
+		digest, err = img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+
+		dir := GinkgoT().TempDir()
+		privateKeyFile, publicKeyFile, err := testutil.WriteKeyPair(dir, "cosign")
+		Expect(err).NotTo(HaveOccurred())
+		options.PromoteImageOptions.SigningKey = privateKeyFile
+		options.PromoteImageOptions.VerificationKey = publicKeyFile
+		keys, err = loadPromoteKeys(&options.PromoteImageOptions)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	signSource := func(imageDigest v1.Hash) {
+		source, err := name.NewRepository(options.PromoteImageOptions.SourceRegistry + "/fedora")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys.signer.Sign(context.Background(), source, imageDigest)).To(Succeed())
+	}
+
+	It("should require a verification key", func() {
+		options.PromoteImageOptions.VerificationKey = ""
+		_, err := loadPromoteKeys(&options.PromoteImageOptions)
+		Expect(err).To(MatchError(ContainSubstring("verification key is required")))
+	})
+
+	It("should refuse to promote images without a verification key", func() {
+		signSource(digest)
+		keys.verifier = nil
+		err := promoteArtifact(context.Background(), artifact, verified("fedora:38"), options, keys)
+		Expect(err).To(MatchError(ContainSubstring("no verification key")))
+
+		_, err = remote.Head(mustTag(options.PromoteImageOptions.TargetRegistry + "/fedora:38"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should refuse to promote images without a valid signature", func() {
+		err := promoteArtifact(context.Background(), artifact, verified("fedora:38"), options, keys)
+		Expect(err).To(HaveOccurred())
+
+		_, err = remote.Head(mustTag(options.PromoteImageOptions.TargetRegistry + "/fedora:38"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should promote signed images and sign them in the target registry", func() {
+		signSource(digest)
+
+		Expect(promoteArtifact(context.Background(), artifact, verified("fedora:38", "fedora:38-1.6"), options, keys)).To(Succeed())
+
+		for _, tag := range []string{"fedora:38", "fedora:38-1.6"} {
+			descriptor, err := remote.Head(mustTag(options.PromoteImageOptions.TargetRegistry + "/" + tag))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(descriptor.Digest).To(Equal(digest))
+		}
+		target, err := name.NewRepository(options.PromoteImageOptions.TargetRegistry + "/fedora")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keys.verifier.Verify(context.Background(), target, digest)).To(Succeed())
+	})
+
+	It("should promote the provenance and record the verification", func() {
+		signSource(digest)
+		source, err := name.NewRepository(options.PromoteImageOptions.SourceRegistry + "/fedora")
+		Expect(err).NotTo(HaveOccurred())
+		statement := provenance.New(source.Name(), digest, "fedora:38", &api.ArtifactDetails{
+			SHA256Sum:   "d6e00c2b4ff8a0d11e8e5b3a8fa6cc8d8dd5b6d8b5df6b3e6a3c2b51dc80e3de",
+			DownloadURL: "https://download.fedoraproject.org/fedora.qcow2",
+		}, "https://download.fedoraproject.org/fedora.qcow2", time.Now(), time.Now())
+		Expect(provenance.Attach(context.Background(), source, digest, statement)).To(Succeed())
+
+		result := verified("fedora:38")
+		result.Tests = map[string]string{"Boot": api.TestPassed, "SSH": api.TestPassed}
+		Expect(promoteArtifact(context.Background(), artifact, result, options, keys)).To(Succeed())
+
+		target, err := name.NewRepository(options.PromoteImageOptions.TargetRegistry + "/fedora")
+		Expect(err).NotTo(HaveOccurred())
+		promoted, err := provenance.Fetch(context.Background(), target, digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(promoted.Subject[0].Name).To(Equal(target.Name()))
+		verification, tests, exists := promoted.Verification()
+		Expect(exists).To(BeTrue())
+		Expect(verification).To(Equal(api.TestPassed))
+		Expect(tests).To(Equal(result.Tests))
+
+		_, err = provenance.FetchSBOM(context.Background(), target, digest)
+		Expect(err).To(MatchError(provenance.ErrNotFound))
+	})
+
+	It("should promote the SBOM", func() {
+		signSource(digest)
+		source, err := name.NewRepository(options.PromoteImageOptions.SourceRegistry + "/fedora")
+		Expect(err).NotTo(HaveOccurred())
+		sbom := provenance.NewSBOM(source.Name(), digest, artifact.Metadata(), &api.ArtifactDetails{
+			DownloadURL: "https://download.fedoraproject.org/fedora.qcow2",
+		}, time.Now())
+		Expect(provenance.AttachSBOM(context.Background(), source, digest, sbom)).To(Succeed())
+
+		Expect(promoteArtifact(context.Background(), artifact, verified("fedora:38"), options, keys)).To(Succeed())
+
+		target, err := name.NewRepository(options.PromoteImageOptions.TargetRegistry + "/fedora")
+		Expect(err).NotTo(HaveOccurred())
+		promoted, err := provenance.FetchSBOM(context.Background(), target, digest)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(promoted.Subject[0].Name).To(Equal(target.Name()))
+		Expect(promoted.Predicate).To(Equal(sbom.Predicate))
+	})
+
+	It("should preserve the layer compression", func() {
+		imageName := filepath.Join(GinkgoT().TempDir(), "image")
+		Expect(os.WriteFile(imageName, []byte("hello"), 0o600)).To(Succeed())
+		img, err := build.ContainerDisk(imageName, "checksum", time.Unix(0, 0), build.WithLayerCompression(build.CompressionZstdChunked))
+		Expect(err).NotTo(HaveOccurred())
+		defer img.Close()
+		Expect(remote.Write(mustTag(options.PromoteImageOptions.SourceRegistry+"/fedora:38"), img)).To(Succeed())
+		expected, err := img.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		compressed, err := img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+		signSource(compressed)
+
+		Expect(promoteArtifact(context.Background(), artifact, verified("fedora:38"), options, keys)).To(Succeed())
+
+		promoted, err := remote.Image(mustTag(options.PromoteImageOptions.TargetRegistry + "/fedora:38"))
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := promoted.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Layers).To(Equal(expected.Layers))
+		cf, err := promoted.ConfigFile()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(build.LayerCompression(cf.Config.Labels)).To(Equal(build.CompressionZstdChunked))
+	})
+})
+
+func mustTag(ref string) name.Tag {
+	tag, err := name.NewTag(ref)
+	Expect(err).NotTo(HaveOccurred())
+	return tag
+}
+
+func TestImages(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Images Suite")
+}
+
+func verified(tags ...string) *api.ArtifactResult {
+	return &api.ArtifactResult{Tags: tags, Stage: StageVerify}
+}
@@ -1,25 +1,36 @@
 package images
 
 import (
+	"compress/bzip2"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/containers/image/v5/pkg/compression/types"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/klauspost/compress/zstd"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/ulikunitz/xz"
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/pkg/api"
 	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/disk"
+	"kubevirt.io/containerdisks/pkg/history"
 	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/provenance"
 	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/signing"
 )
 
 type buildAndPublish struct {
@@ -28,11 +39,14 @@ type buildAndPublish struct {
 	Options *common.Options
 	Repo    repository.Repository
 	Getter  http.Getter
+	Signer  *signing.Signer
+	History *history.History
 }
 
 func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 	options.PublishImagesOptions = common.PublishImageOptions{
-		SourceRegistry: "quay.io/containerdisks",
+		LayerCompression: string(build.CompressionGzip),
+		SourceRegistry:   "quay.io/containerdisks",
 	}
 
 	publishCmd := &cobra.Command{
@@ -43,6 +57,27 @@ func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 				options.PublishImagesOptions.TargetRegistry = options.PublishImagesOptions.SourceRegistry
 			}
 
+			if _, err := build.ParseCompression(options.PublishImagesOptions.LayerCompression); err != nil {
+				logrus.Fatal(err)
+			}
+
+			var signer *signing.Signer
+			if options.PublishImagesOptions.SigningKey != "" {
+				var err error
+				if signer, err = signing.LoadSigner(options.PublishImagesOptions.SigningKey); err != nil {
+					logrus.Fatal(err)
+				}
+			}
+
+			var checksumHistory *history.History
+			if options.PublishImagesOptions.ChecksumHistoryFile != "" {
+				var err error
+				if checksumHistory, err = history.Load(options.PublishImagesOptions.ChecksumHistoryFile); err != nil {
+					logrus.Fatal(err)
+				}
+				checksumHistory.Accept(options.PublishImagesOptions.AcceptChecksumChanges...)
+			}
+
 			focusMatched, resultsChan, workerErr := spawnWorkers(cmd.Context(), options, func(e *common.Entry) (*api.ArtifactResult, error) {
 				errString := ""
 
@@ -50,8 +85,10 @@ func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 					Ctx:     cmd.Context(),
 					Log:     common.Logger(e.Artifact),
 					Options: options,
-					Repo:    &repository.RepositoryImpl{},
-					Getter:  &http.HTTPGetter{},
+					Repo:    common.NewRepository(options),
+					Getter:  http.DefaultGetter,
+					Signer:  signer,
+					History: checksumHistory,
 				}
 				tags, err := b.Do(e, time.Now())
 				if err != nil {
@@ -82,6 +119,11 @@ func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 				if err := writeResultsFile(options.ImagesOptions.ResultsFile, results); err != nil {
 					logrus.Fatal(err)
 				}
+				if checksumHistory != nil {
+					if err := checksumHistory.Save(options.PublishImagesOptions.ChecksumHistoryFile); err != nil {
+						logrus.Fatal(err)
+					}
+				}
 			}
 
 			if workerErr != nil {
@@ -93,80 +135,366 @@ func NewPublishImagesCommand(options *common.Options) *cobra.Command {
 			}
 		},
 	}
+	publishCmd.Flags().StringSliceVar(&options.PublishImagesOptions.AcceptChecksumChanges, "accept-checksum-change",
+		options.PublishImagesOptions.AcceptChecksumChanges, "Accept a changed upstream checksum of an immutable name:tag reference")
+	publishCmd.Flags().StringVar(&options.PublishImagesOptions.ChecksumHistoryFile, "checksum-history-file",
+		options.PublishImagesOptions.ChecksumHistoryFile, "State file to track the upstream checksums of all references in")
 	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.ForceBuild, "force",
 		options.PublishImagesOptions.ForceBuild, "Force a rebuild and push")
+	publishCmd.Flags().StringVar(&options.PublishImagesOptions.LayerCompression, "layer-compression",
+		options.PublishImagesOptions.LayerCompression, "Compression of the layer: gzip, zstd, uncompressed, estargz or zstd:chunked")
+	publishCmd.Flags().StringToStringVar(&options.PublishImagesOptions.Mirrors, "mirror",
+		options.PublishImagesOptions.Mirrors, "Download artifacts of a host from a mirror first, e.g. host=https://mirror.example.com/path")
 	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.NoFail, "no-fail",
 		options.PublishImagesOptions.NoFail, "Return success even if a worker fails")
+	publishCmd.Flags().StringVar(&options.PublishImagesOptions.SigningKey, "signing-key",
+		options.PublishImagesOptions.SigningKey, "PEM encoded ECDSA private key to sign pushed containerdisks with")
 	publishCmd.Flags().StringVar(&options.PublishImagesOptions.SourceRegistry, "source-registry",
 		options.PublishImagesOptions.SourceRegistry, "Registry to check if updates are needed")
 	publishCmd.Flags().StringVar(&options.PublishImagesOptions.TargetRegistry, "target-registry",
 		options.PublishImagesOptions.TargetRegistry, "Registry to push built containerdisks to")
+	publishCmd.Flags().BoolVar(&options.PublishImagesOptions.VerifyReproducible, "verify-reproducible",
+		options.PublishImagesOptions.VerifyReproducible, "Build every containerdisk twice and fail if the digests differ")
 
 	return publishCmd
 }
 
 func (b *buildAndPublish) Do(entry *common.Entry, timestamp time.Time) ([]string, error) {
 	description := entry.Artifact.Metadata().Describe()
-	artifactInfo, err := entry.Artifact.Inspect()
+	artifactInfo, err := common.Inspect(b.Ctx, entry.Artifact)
 	if err != nil {
 		return nil, fmt.Errorf("error introspecting artifact %q: %v", description, err)
 	}
-	b.Log.Infof("Remote artifact checksum: %q", artifactInfo.SHA256Sum)
+	checksumLabel, checksum := checksumLabelAndValue(artifactInfo)
+	b.Log.Infof("Remote artifact checksum: %q", checksum)
 
-	imageSha, err := b.getImageSha(description)
+	if err = b.checkChecksum(entry, artifactInfo, checksum); err != nil {
+		return nil, err
+	}
+
+	upToDate, err := b.isUpToDate(entry, checksumLabel, checksum)
 	if err != nil {
 		return nil, err
 	}
-	if imageSha == artifactInfo.SHA256Sum && !b.Options.PublishImagesOptions.ForceBuild {
+	if upToDate && !b.Options.PublishImagesOptions.ForceBuild {
 		b.Log.Info("Nothing to do.")
-		return nil, nil
+		return nil, b.observeChecksum(entry, artifactInfo, checksum, timestamp)
 	}
 	if errors.Is(b.Ctx.Err(), context.Canceled) {
 		return nil, b.Ctx.Err()
 	}
 
-	b.Log.Infof("Rebuild needed, downloading %q ...", artifactInfo.DownloadURL)
-	file, err := b.getArtifact(artifactInfo)
+	b.Log.Info("Rebuild needed, downloading ...")
+	startedOn := time.Now()
+	artifact, err := b.getArtifact(artifactInfo)
 	if err != nil {
 		return nil, err
 	}
-	defer os.Remove(file)
+	defer os.Remove(artifact.File)
+
+	b.Log.Info("Inspecting disk image ...")
+	diskInfo, err := disk.Inspect(artifact.File)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting the disk image of artifact %q: %v", description, err)
+	}
+	b.Log.Infof("Disk image format: %q, virtual size: %d", diskInfo.Format, diskInfo.VirtualSize)
 
 	b.Log.Info("Building containerdisk ...")
-	containerDisk, err := build.ContainerDisk(file, artifactInfo.SHA256Sum)
+	opts := imageLabels(entry.Artifact.Metadata(), artifactInfo, artifact, diskInfo)
+	opts = append(opts, build.WithLayerCompression(b.layerCompression()))
+	lastModified, err := b.upstreamModificationTime(artifactInfo, artifact)
+	if err != nil {
+		return nil, err
+	}
+	buildTime, err := buildTimestamp(lastModified)
+	if err != nil {
+		return nil, err
+	}
+	containerDisk, err := build.ContainerDisk(artifact.File, artifactInfo.SHA256Sum, buildTime, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("error creating the containerdisk : %v", err)
 	}
+	// Frees the temporary file of chunked layers once all tags are pushed
+	defer containerDisk.Close()
+	if b.Options.PublishImagesOptions.VerifyReproducible {
+		if err = b.verifyReproducible(containerDisk, artifact.File, artifactInfo.SHA256Sum, buildTime, opts); err != nil {
+			return nil, err
+		}
+	}
+	finishedOn := time.Now()
 	if errors.Is(b.Ctx.Err(), context.Canceled) {
 		return nil, b.Ctx.Err()
 	}
 
+	// The first name is the unique timestamp tag. The image is signed and its provenance is
+	// attached before the tags which isUpToDate checks are moved, so that a failure leads to
+	// a rebuild instead of an unsigned image behind the user facing tags.
 	names := prepareTags(timestamp, b.Options.PublishImagesOptions.TargetRegistry, entry, artifactInfo)
-	for _, name := range names {
-		if err := b.pushImage(containerDisk, name); err != nil {
-			return nil, err
-		}
+	if err = b.pushImage(containerDisk, names[0]); err != nil {
+		return nil, err
+	}
+
+	digest, err := containerDisk.Digest()
+	if err != nil {
+		return nil, err
+	}
+	if err = b.signImage(digest, names[0]); err != nil {
+		return nil, err
+	}
+	repo := path.Join(b.Options.PublishImagesOptions.TargetRegistry, entry.Artifact.Metadata().Name)
+	statement := provenance.New(repo, digest, description, artifactInfo, artifact.URL, startedOn, finishedOn)
+	sbom := provenance.NewSBOM(repo, digest, entry.Artifact.Metadata(), artifactInfo, finishedOn)
+	if err = b.attachProvenance(statement, sbom, names[0], digest); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names[1:] {
 		if errors.Is(b.Ctx.Err(), context.Canceled) {
 			return nil, b.Ctx.Err()
 		}
+		if err = b.pushImage(containerDisk, name); err != nil {
+			return nil, err
+		}
+	}
+
+	// The checksum history is only updated once the containerdisk is published, so that a
+	// failed build does not hide a checksum change from the next run.
+	if err = b.observeChecksum(entry, artifactInfo, checksum, timestamp); err != nil {
+		return nil, err
 	}
 
 	return prepareTags(timestamp, "", entry, artifactInfo), nil
 }
 
-func (b *buildAndPublish) getImageSha(description string) (imageSha string, err error) {
+// sourceURL is the source of the containerdisks in the OCI annotations.
+const sourceURL = "https://github.com/kubevirt/containerdisks"
+
+// imageLabels returns the labels which describe the containerdisk, its upstream artifact and its disk image.
+func imageLabels(metadata *api.Metadata, artifactInfo *api.ArtifactDetails, artifact *downloadedArtifact,
+	diskInfo *disk.Info) []build.Option {
+	var uniqueTags []string
+	for _, tag := range artifactInfo.AdditionalUniqueTags {
+		if tag != "" {
+			uniqueTags = append(uniqueTags, tag)
+		}
+	}
+
+	labels := map[string]string{
+		build.AnnotationTitle:          metadata.Name,
+		build.AnnotationDescription:    plainDescription(metadata.Description),
+		build.AnnotationVersion:        metadata.Version,
+		build.AnnotationURL:            metadata.URL,
+		build.AnnotationLicenses:       metadata.Licenses,
+		build.AnnotationSource:         sourceURL,
+		build.AnnotationRevision:       provenance.Commit(),
+		build.LabelDownloadURL:         artifactInfo.DownloadURL,
+		build.LabelUniqueTags:          strings.Join(uniqueTags, ","),
+		build.LabelSha512Sum:           artifactInfo.SHA512Sum,
+		build.LabelDiskFormat:          diskInfo.Format,
+		build.LabelVirtualSize:         strconv.FormatUint(diskInfo.VirtualSize, 10),
+		build.LabelDiskShaSum:          artifact.DiskSHA256Sum,
+		build.LabelDefaultInstancetype: metadata.DefaultInstancetype,
+		build.LabelDefaultPreference:   metadata.DefaultPreference,
+	}
+
+	var opts []build.Option
+	for key, value := range labels {
+		if value != "" {
+			opts = append(opts, build.WithLabel(key, value))
+		}
+	}
+
+	return opts
+}
+
+var (
+	markdownLinkRex = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	htmlTagRex      = regexp.MustCompile(`<[^>]*>`)
+)
+
+// plainDescription returns the first line of a Markdown description without links and HTML tags.
+func plainDescription(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		line = htmlTagRex.ReplaceAllString(markdownLinkRex.ReplaceAllString(line, "$1"), "")
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+
+	return ""
+}
+
+// upstreamModificationTime returns the modification time of the canonical DownloadURL of the
+// artifact, so that the build time does not depend on which location served the artifact.
+// It is not needed if SOURCE_DATE_EPOCH is set.
+func (b *buildAndPublish) upstreamModificationTime(artifactInfo *api.ArtifactDetails, artifact *downloadedArtifact) (time.Time, error) {
+	if _, exists := sourceDateEpoch(); exists || artifact.URL == artifactInfo.DownloadURL {
+		return artifact.LastModified, nil
+	}
+
+	artifactReader, err := b.Getter.GetWithChecksumAndContext(b.Ctx, artifactInfo.DownloadURL, false)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"error reading the modification time of %q, set SOURCE_DATE_EPOCH to build from %q: %v",
+			artifactInfo.DownloadURL, artifact.URL, err,
+		)
+	}
+	artifactReader.Close()
+
+	return artifactReader.LastModified(), nil
+}
+
+func sourceDateEpoch() (string, bool) {
+	epoch, exists := os.LookupEnv("SOURCE_DATE_EPOCH")
+	return epoch, exists && epoch != ""
+}
+
+// buildTimestamp returns the time all timestamps in a containerdisk are set to. It is taken
+// from SOURCE_DATE_EPOCH or else from the upstream modification time of the artifact, so that
+// rebuilding the same upstream file results in the same image.
+func buildTimestamp(lastModified time.Time) (time.Time, error) {
+	if epoch, exists := sourceDateEpoch(); exists {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %v", epoch, err)
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	if !lastModified.IsZero() {
+		return lastModified.UTC(), nil
+	}
+
+	return time.Unix(0, 0).UTC(), nil
+}
+
+// verifyReproducible builds the containerdisk a second time and fails if its digest differs.
+func (b *buildAndPublish) verifyReproducible(containerDisk v1.Image, file, checksum string, buildTime time.Time,
+	opts []build.Option) error {
+	b.Log.Info("Rebuilding containerdisk to verify that it is reproducible ...")
+	rebuilt, err := build.ContainerDisk(file, checksum, buildTime, opts...)
+	if err != nil {
+		return fmt.Errorf("error rebuilding the containerdisk: %v", err)
+	}
+	defer rebuilt.Close()
+
+	digest, err := containerDisk.Digest()
+	if err != nil {
+		return err
+	}
+	rebuiltDigest, err := rebuilt.Digest()
+	if err != nil {
+		return err
+	}
+	if digest != rebuiltDigest {
+		return fmt.Errorf("containerdisk is not reproducible, the builds have digests %s and %s", digest, rebuiltDigest)
+	}
+	b.Log.Infof("Containerdisk %s is reproducible", digest)
+
+	return nil
+}
+
+// checksumReferences returns the checksum history references of the entry and all unique
+// tags of the artifact. Unique tags are immutable unless they are moving tags.
+func checksumReferences(entry *common.Entry, artifactInfo *api.ArtifactDetails) []history.Reference {
+	movingTags := map[string]bool{}
+	for _, tag := range artifactInfo.MovingTags {
+		movingTags[tag] = true
+	}
+
+	metadata := entry.Artifact.Metadata()
+	refs := []history.Reference{{Name: metadata.Describe(), Immutable: entry.Immutable}}
+	for _, tag := range artifactInfo.AdditionalUniqueTags {
+		if tag == "" {
+			continue
+		}
+		refs = append(refs, history.Reference{
+			Name:      fmt.Sprintf("%s:%s", metadata.Name, tag),
+			Immutable: entry.Immutable || !movingTags[tag],
+		})
+	}
+
+	return refs
+}
+
+// checkChecksum fails if the checksum of an immutable reference of the entry changed.
+func (b *buildAndPublish) checkChecksum(entry *common.Entry, artifactInfo *api.ArtifactDetails, checksum string) error {
+	if b.History == nil {
+		return nil
+	}
+
+	if err := b.History.Check(checksum, checksumReferences(entry, artifactInfo)...); err != nil {
+		return fmt.Errorf("refusing to rebuild, accept the change with --accept-checksum-change if it is expected: %w", err)
+	}
+
+	return nil
+}
+
+// observeChecksum records checksum in the checksum history for the entry and all unique tags
+// of the artifact. It is called once the containerdisk with checksum is published.
+func (b *buildAndPublish) observeChecksum(entry *common.Entry, artifactInfo *api.ArtifactDetails, checksum string, now time.Time) error {
+	if b.History == nil {
+		return nil
+	}
+
+	return b.History.Observe(checksum, now, checksumReferences(entry, artifactInfo)...)
+}
+
+// isUpToDate returns true if the image of the entry and the images of all its aliases
+// contain the artifact with checksum in a layer with the configured compression.
+func (b *buildAndPublish) isUpToDate(entry *common.Entry, checksumLabel, checksum string) (bool, error) {
+	metadata := entry.Artifact.Metadata()
+	descriptions := []string{metadata.Describe()}
+	for _, alias := range entry.Aliases {
+		descriptions = append(descriptions, fmt.Sprintf("%s:%s", metadata.Name, alias))
+	}
+
+	for _, description := range descriptions {
+		labels, err := b.getImageLabels(description)
+		if err != nil {
+			return false, err
+		}
+		if labels == nil {
+			return false, nil
+		}
+		b.Log.Infof("Latest containerdisk checksum: %q", labels[checksumLabel])
+		if labels[checksumLabel] != checksum {
+			return false, nil
+		}
+		if build.LayerCompression(labels) != b.layerCompression() {
+			b.Log.Infof("Layer compression changes from %q to %q", build.LayerCompression(labels), b.layerCompression())
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (b *buildAndPublish) getImageLabels(description string) (labels map[string]string, err error) {
 	imageName := path.Join(b.Options.PublishImagesOptions.SourceRegistry, description)
-	imageInfo, err := b.Repo.ImageMetadata(imageName, b.Options.AllowInsecureRegistry)
+	imageInfo, err := b.Repo.ImageMetadata(b.Ctx, imageName, b.Options.AllowInsecureRegistry)
 	if err != nil {
 		err = b.handleMetadataError(imageName, err)
 	} else {
-		b.Log.Infof("Latest containerdisk checksum: %q", imageInfo.Labels[build.LabelShaSum])
-		imageSha = imageInfo.Labels[build.LabelShaSum]
+		labels = imageInfo.Labels
 	}
 
 	return
 }
 
+func (b *buildAndPublish) layerCompression() build.Compression {
+	return build.Compression(b.Options.PublishImagesOptions.LayerCompression)
+}
+
+// checksumLabelAndValue returns the checksum which is used to detect changes of an artifact
+// and the image label it is stored in. SHA512 is only used if no SHA256 checksum is available.
+func checksumLabelAndValue(artifactInfo *api.ArtifactDetails) (label, checksum string) {
+	if artifactInfo.SHA256Sum == "" && artifactInfo.SHA512Sum != "" {
+		return build.LabelSha512Sum, artifactInfo.SHA512Sum
+	}
+
+	return build.LabelShaSum, artifactInfo.SHA256Sum
+}
+
 func (b *buildAndPublish) handleMetadataError(imageName string, err error) error {
 	switch {
 	case repository.IsRepositoryUnknownError(err):
@@ -182,56 +510,139 @@ func (b *buildAndPublish) handleMetadataError(imageName string, err error) error
 	return nil
 }
 
-func (b *buildAndPublish) getArtifact(artifactInfo *api.ArtifactDetails) (string, error) {
-	artifactReader, err := b.Getter.GetWithChecksumAndContext(b.Ctx, artifactInfo.DownloadURL)
+// downloadedArtifact is an artifact which was downloaded and uncompressed to a local file.
+type downloadedArtifact struct {
+	// File is the uncompressed disk image.
+	File string
+	// URL is the location the artifact was downloaded from.
+	URL string
+	// DiskSHA256Sum is the checksum of the uncompressed disk image.
+	DiskSHA256Sum string
+	// LastModified is the upstream modification time of the artifact, zero if unknown or if
+	// the artifact was not downloaded from its canonical DownloadURL.
+	LastModified time.Time
+}
+
+// getArtifact downloads the artifact from the first location which serves it with a matching checksum.
+func (b *buildAndPublish) getArtifact(artifactInfo *api.ArtifactDetails) (*downloadedArtifact, error) {
+	if artifactInfo.SHA256Sum == "" && artifactInfo.SHA512Sum == "" {
+		return nil, errors.New("artifact has no checksum to verify the download with")
+	}
+
+	urls := http.MirrorURLs(artifactInfo.DownloadURLs(), b.Options.PublishImagesOptions.Mirrors)
+	var lastErr error
+	for _, url := range urls {
+		artifact, err := b.downloadArtifact(url, artifactInfo)
+		if err == nil {
+			return artifact, nil
+		}
+		if b.Ctx.Err() != nil {
+			return nil, b.Ctx.Err()
+		}
+		b.Log.WithError(err).Warnf("Failed to download %q", url)
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("error downloading the artifact from %d locations, last error: %v", len(urls), lastErr)
+}
+
+// downloadArtifact only returns the downloaded artifact if its checksum matches.
+func (b *buildAndPublish) downloadArtifact(url string, artifactInfo *api.ArtifactDetails) (*downloadedArtifact, error) {
+	b.Log.Infof("Downloading %q ...", url)
+	artifactReader, err := b.Getter.GetWithChecksumAndContext(b.Ctx, url, artifactInfo.SHA512Sum != "")
 	if err != nil {
-		return "", fmt.Errorf("error opening a connection to the specified download location: %v", err)
+		return nil, fmt.Errorf("error opening a connection to the specified download location: %v", err)
 	}
 	defer artifactReader.Close()
 
-	file, err := b.readArtifact(artifactReader, artifactInfo.Compression)
+	artifact, err := b.readArtifact(artifactReader, artifactInfo.Compression)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	if errors.Is(b.Ctx.Err(), context.Canceled) {
-		return "", b.Ctx.Err()
+		os.Remove(artifact.File)
+		return nil, b.Ctx.Err()
+	}
+	artifact.URL = url
+	if url == artifactInfo.DownloadURL {
+		artifact.LastModified = artifactReader.LastModified()
+	}
+
+	if artifactInfo.SHA512Sum != "" {
+		checksum := artifactReader.SHA512Checksum()
+		if checksum != artifactInfo.SHA512Sum {
+			os.Remove(artifact.File)
+			return nil, fmt.Errorf("expected SHA512 checksum %q but got %q", artifactInfo.SHA512Sum, checksum)
+		}
 	}
 
 	checksum := artifactReader.Checksum()
-	if checksum != artifactInfo.SHA256Sum {
-		return "", fmt.Errorf("expected checksum %q but got %q", artifactInfo.SHA256Sum, checksum)
+	if artifactInfo.SHA256Sum == "" {
+		// The download was verified with the SHA512 checksum, remember the SHA256 checksum for the image label
+		artifactInfo.SHA256Sum = checksum
+	} else if checksum != artifactInfo.SHA256Sum {
+		os.Remove(artifact.File)
+		return nil, fmt.Errorf("expected checksum %q but got %q", artifactInfo.SHA256Sum, checksum)
 	}
 
-	return file, nil
+	return artifact, nil
 }
 
-func (b *buildAndPublish) readArtifact(artifactReader http.ReadCloserWithChecksum, compression string) (string, error) {
+func (b *buildAndPublish) readArtifact(artifactReader http.ReadCloserWithChecksum, compression string) (*downloadedArtifact, error) {
 	var err error
 
 	// Initialize reader with the artifactReader for the case where no compression is used
 	var reader io.Reader = artifactReader
-	if compression == types.GzipAlgorithmName {
+	switch compression {
+	case types.GzipAlgorithmName:
 		reader, err = gzip.NewReader(artifactReader)
 		if err != nil {
-			return "", fmt.Errorf("error creating a gunzip reader for the specified download location: %v", err)
+			return nil, fmt.Errorf("error creating a gunzip reader for the specified download location: %v", err)
 		}
-	} else if compression == types.XzAlgorithmName {
+	case types.XzAlgorithmName:
 		reader, err = xz.NewReader(artifactReader)
 		if err != nil {
-			return "", fmt.Errorf("error creating a lzma reader for the specified download location: %v", err)
+			return nil, fmt.Errorf("error creating a lzma reader for the specified download location: %v", err)
 		}
+	case types.Bzip2AlgorithmName:
+		reader = bzip2.NewReader(artifactReader)
+	case types.ZstdAlgorithmName:
+		decoder, err := zstd.NewReader(artifactReader)
+		if err != nil {
+			return nil, fmt.Errorf("error creating a zstd reader for the specified download location: %v", err)
+		}
+		defer decoder.Close()
+		reader = decoder
 	}
 
 	file, err := os.CreateTemp("", "containerdisks")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer file.Close()
 
+	diskSHA256Sum, err := b.writeDisk(file, reader)
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &downloadedArtifact{
+		File:          file.Name(),
+		DiskSHA256Sum: diskSHA256Sum,
+	}, nil
+}
+
+// writeDisk writes the uncompressed disk image from reader to file and returns its checksum.
+func (b *buildAndPublish) writeDisk(file io.Writer, reader io.Reader) (string, error) {
+	// Compute the checksum of the uncompressed disk image while writing it
+	hash := sha256.New()
+	writer := io.MultiWriter(file, hash)
+
 	// Uncompress disks in chunks up to size defined below
 	const chunkSize = 1024 * 1024 * 50 // MiB
 	for {
-		_, err := io.CopyN(file, reader, chunkSize)
+		_, err := io.CopyN(writer, reader, chunkSize)
 		if err != nil {
 			if err == io.EOF {
 				break
@@ -243,7 +654,7 @@ func (b *buildAndPublish) readArtifact(artifactReader http.ReadCloserWithChecksu
 		}
 	}
 
-	return file.Name(), nil
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
 func (b *buildAndPublish) pushImage(containerDisk v1.Image, name string) error {
@@ -260,6 +671,48 @@ func (b *buildAndPublish) pushImage(containerDisk v1.Image, name string) error {
 	return nil
 }
 
+// signImage signs digest in the repository of name, if a signer is configured.
+func (b *buildAndPublish) signImage(digest v1.Hash, name string) error {
+	if b.Signer == nil {
+		return nil
+	}
+	if b.Options.DryRun {
+		b.Log.Infof("Dry run enabled, not signing %s", name)
+		return nil
+	}
+
+	b.Log.Infof("Signing %s@%s", name, digest)
+	if err := b.Repo.SignImage(b.Ctx, b.Signer, name, digest, b.Options.AllowInsecureRegistry); err != nil {
+		b.Log.WithError(err).Error("Failed to sign image")
+		return err
+	}
+
+	return nil
+}
+
+// attachProvenance attaches statement and sbom to digest in the repository of name.
+func (b *buildAndPublish) attachProvenance(statement *provenance.Statement, sbom *provenance.SBOMStatement, name string,
+	digest v1.Hash) error {
+	if b.Options.DryRun {
+		b.Log.Infof("Dry run enabled, not attaching the provenance and SBOM to %s", name)
+		return nil
+	}
+
+	b.Log.Infof("Attaching the provenance to %s@%s", name, digest)
+	if err := b.Repo.AttachProvenance(b.Ctx, statement, name, digest, b.Options.AllowInsecureRegistry); err != nil {
+		b.Log.WithError(err).Error("Failed to attach the provenance")
+		return err
+	}
+
+	b.Log.Infof("Attaching the SBOM to %s@%s", name, digest)
+	if err := b.Repo.AttachSBOM(b.Ctx, sbom, name, digest, b.Options.AllowInsecureRegistry); err != nil {
+		b.Log.WithError(err).Error("Failed to attach the SBOM")
+		return err
+	}
+
+	return nil
+}
+
 func prepareTags(timestamp time.Time, registry string, entry *common.Entry, artifactDetails *api.ArtifactDetails) []string {
 	metadata := entry.Artifact.Metadata()
 	imageName := path.Join(registry, metadata.Describe())
@@ -274,6 +727,10 @@ func prepareTags(timestamp time.Time, registry string, entry *common.Entry, arti
 	// the least specific tag is last
 	names = append(names, imageName)
 
+	for _, alias := range entry.Aliases {
+		names = append(names, fmt.Sprintf("%s:%s", path.Join(registry, metadata.Name), alias))
+	}
+
 	if entry.UseForLatest {
 		names = append(names, fmt.Sprintf("%s:%s", path.Join(registry, metadata.Name), "latest"))
 	}
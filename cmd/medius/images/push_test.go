@@ -0,0 +1,366 @@
+package images
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	gohttp "net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/artifacts/generic"
+	"kubevirt.io/containerdisks/cmd/medius/common"
+	"kubevirt.io/containerdisks/pkg/api"
+	"kubevirt.io/containerdisks/pkg/build"
+	"kubevirt.io/containerdisks/pkg/disk"
+	"kubevirt.io/containerdisks/pkg/history"
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/repository"
+	"kubevirt.io/containerdisks/pkg/signing"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Push", func() {
+	lastModified := time.Date(2023, 4, 18, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	DescribeTable("buildTimestamp should prefer SOURCE_DATE_EPOCH over the upstream modification time",
+		func(sourceDateEpoch string, lastModified, expected time.Time) {
+			if sourceDateEpoch != "" {
+				Expect(os.Setenv("SOURCE_DATE_EPOCH", sourceDateEpoch)).To(Succeed())
+				DeferCleanup(os.Unsetenv, "SOURCE_DATE_EPOCH")
+			}
+
+			timestamp, err := buildTimestamp(lastModified)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(timestamp).To(Equal(expected))
+		},
+		Entry("with SOURCE_DATE_EPOCH", "1700000000", lastModified, time.Unix(1700000000, 0).UTC()),
+		Entry("with the upstream modification time", "", lastModified, lastModified.UTC()),
+		Entry("without any timestamp", "", time.Time{}, time.Unix(0, 0).UTC()),
+	)
+
+	DescribeTable("plainDescription should return the first line without Markdown links and HTML tags",
+		func(description, expected string) {
+			Expect(plainDescription(description)).To(Equal(expected))
+		},
+		Entry("with a logo and a link",
+			`<img src="https://example.com/logo.png" alt="drawing" width="15"/> Fedora [Cloud](https://example.com/cloud) images for KubeVirt.
+
+Visit [getfedora.org](https://getfedora.org/) to learn more.`,
+			"Fedora Cloud images for KubeVirt."),
+		Entry("with leading empty lines", "\n\nUbuntu images for KubeVirt.\n", "Ubuntu images for KubeVirt."),
+		Entry("without a description", "", ""),
+	)
+
+	It("imageLabels should describe the containerdisk", func() {
+		opts := imageLabels(
+			&api.Metadata{
+				Name:              "fedora",
+				Version:           "38",
+				Description:       "Fedora images.",
+				URL:               "https://fedoraproject.org/",
+				Licenses:          "LicenseRef-Fedora",
+				DefaultPreference: "fedora",
+			},
+			&api.ArtifactDetails{
+				DownloadURL:          "https://download.fedoraproject.org/Fedora-Cloud-Base-38-1.6.x86_64.qcow2",
+				AdditionalUniqueTags: []string{"38-1.6", "", "38-latest"},
+			},
+			&downloadedArtifact{DiskSHA256Sum: "abc"},
+			&disk.Info{Format: disk.FormatQcow2, VirtualSize: 5368709120},
+		)
+
+		cf := &v1.ConfigFile{Config: v1.Config{Labels: map[string]string{}}}
+		for _, opt := range opts {
+			opt(cf)
+		}
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.AnnotationTitle, "fedora"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.AnnotationVersion, "38"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.AnnotationDescription, "Fedora images."))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.AnnotationURL, "https://fedoraproject.org/"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.AnnotationSource, sourceURL))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.LabelDownloadURL,
+			"https://download.fedoraproject.org/Fedora-Cloud-Base-38-1.6.x86_64.qcow2"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.LabelUniqueTags, "38-1.6,38-latest"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.LabelDiskFormat, "qcow2"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.LabelVirtualSize, "5368709120"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.LabelDiskShaSum, "abc"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.LabelDefaultPreference, "fedora"))
+		Expect(cf.Config.Labels).ToNot(HaveKey(build.LabelDefaultInstancetype))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(build.AnnotationLicenses, "LicenseRef-Fedora"))
+		Expect(cf.Config.Labels).ToNot(HaveKey(build.LabelSha512Sum))
+	})
+
+	It("buildTimestamp should reject an invalid SOURCE_DATE_EPOCH", func() {
+		Expect(os.Setenv("SOURCE_DATE_EPOCH", "yesterday")).To(Succeed())
+		DeferCleanup(os.Unsetenv, "SOURCE_DATE_EPOCH")
+
+		_, err := buildTimestamp(time.Time{})
+		Expect(err).To(MatchError(ContainSubstring("invalid SOURCE_DATE_EPOCH")))
+	})
+
+	It("checksumReferences should treat unique tags as immutable unless they are moving tags", func() {
+		entry := &common.Entry{Artifact: generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "ubuntu", Version: "22.04"})}
+		artifactInfo := &api.ArtifactDetails{
+			AdditionalUniqueTags: []string{"22.04-20230302", "", "jammy"},
+			MovingTags:           []string{"jammy"},
+		}
+
+		Expect(checksumReferences(entry, artifactInfo)).To(Equal([]history.Reference{
+			{Name: "ubuntu:22.04"},
+			{Name: "ubuntu:22.04-20230302", Immutable: true},
+			{Name: "ubuntu:jammy"},
+		}))
+	})
+
+	It("checkChecksum should not record the checksum before the containerdisk is published", func() {
+		entry := &common.Entry{Artifact: generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "fedora", Version: "38"})}
+		artifactInfo := &api.ArtifactDetails{AdditionalUniqueTags: []string{"38-1.6"}}
+		b := &buildAndPublish{History: history.New()}
+
+		Expect(b.checkChecksum(entry, artifactInfo, "aaa")).To(Succeed())
+		_, exists := b.History.Timeline("fedora:38-1.6")
+		Expect(exists).To(BeFalse())
+
+		Expect(b.observeChecksum(entry, artifactInfo, "aaa", lastModified)).To(Succeed())
+		Expect(b.checkChecksum(entry, artifactInfo, "bbb")).To(MatchError(history.ErrChecksumChanged))
+	})
+
+	Context("signImage", func() {
+		var (
+			server   *httptest.Server
+			repo     name.Repository
+			digest   v1.Hash
+			b        *buildAndPublish
+			verifier *signing.Verifier
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+			var err error
+			repo, err = name.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/containerdisks/fedora")
+			Expect(err).NotTo(HaveOccurred())
+
+			img, err := random.Image(1024, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(repo.Tag("38-2304181200"), img)).To(Succeed())
+			digest, err = img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+
+			privateKeyFile, publicKeyFile, err := testutil.WriteKeyPair(GinkgoT().TempDir(), "cosign")
+			Expect(err).NotTo(HaveOccurred())
+			signer, err := signing.LoadSigner(privateKeyFile)
+			Expect(err).NotTo(HaveOccurred())
+			verifier, err = signing.LoadVerifier(publicKeyFile)
+			Expect(err).NotTo(HaveOccurred())
+
+			b = &buildAndPublish{
+				Ctx:     context.Background(),
+				Log:     logrus.NewEntry(logrus.StandardLogger()),
+				Options: &common.Options{},
+				Repo:    &repository.RepositoryImpl{},
+				Signer:  signer,
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should sign the digest in the repository of the image", func() {
+			Expect(b.signImage(digest, repo.Tag("38-2304181200").String())).To(Succeed())
+			Expect(verifier.Verify(context.Background(), repo, digest)).To(Succeed())
+		})
+
+		It("should not sign in dry-run mode", func() {
+			b.Options.DryRun = true
+			Expect(b.signImage(digest, repo.Tag("38-2304181200").String())).To(Succeed())
+			Expect(verifier.Verify(context.Background(), repo, digest)).NotTo(Succeed())
+		})
+
+		It("should not sign without a signing key", func() {
+			b.Signer = nil
+			Expect(b.signImage(digest, repo.Tag("38-2304181200").String())).To(Succeed())
+			Expect(verifier.Verify(context.Background(), repo, digest)).NotTo(Succeed())
+		})
+	})
+
+	Context("getArtifact", func() {
+		const content = "disk image"
+
+		var (
+			server *httptest.Server
+			files  map[string][]byte
+			tmpDir string
+			b      *buildAndPublish
+		)
+
+		BeforeEach(func() {
+			compressed := &bytes.Buffer{}
+			gz := gzip.NewWriter(compressed)
+			_, err := gz.Write([]byte(content))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(gz.Close()).To(Succeed())
+
+			files = map[string][]byte{
+				"/disk.img":    []byte(content),
+				"/other.img":   []byte("other disk image"),
+				"/disk.img.gz": compressed.Bytes(),
+				// A truncated gzip stream fails while the disk image is written
+				"/broken.img.gz": compressed.Bytes()[:compressed.Len()/2],
+			}
+			server = httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+				file, exists := files[r.URL.Path]
+				if !exists {
+					w.WriteHeader(gohttp.StatusInternalServerError)
+					return
+				}
+				if r.URL.Path == "/mirror.img" {
+					w.Header().Set("Last-Modified", time.Now().UTC().Format(gohttp.TimeFormat))
+				} else {
+					w.Header().Set("Last-Modified", lastModified.UTC().Format(gohttp.TimeFormat))
+				}
+				_, _ = w.Write(file)
+			}))
+
+			tmpDir = GinkgoT().TempDir()
+			Expect(os.Setenv("TMPDIR", tmpDir)).To(Succeed())
+			DeferCleanup(os.Unsetenv, "TMPDIR")
+
+			b = &buildAndPublish{
+				Ctx:     context.Background(),
+				Log:     logrus.NewEntry(logrus.StandardLogger()),
+				Options: &common.Options{},
+				Getter:  &http.HTTPGetter{},
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		DescribeTable("should fail over to the next location and remove the temporary files of failed locations",
+			func(downloadPath, mirrorPath, compression string) {
+				artifactInfo := &api.ArtifactDetails{
+					SHA256Sum:   fmt.Sprintf("%x", sha256.Sum256(files[mirrorPath])),
+					DownloadURL: server.URL + downloadPath,
+					MirrorURLs:  []string{server.URL + mirrorPath},
+					Compression: compression,
+				}
+
+				artifact, err := b.getArtifact(artifactInfo)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(artifact.URL).To(Equal(server.URL + mirrorPath))
+				Expect(os.ReadFile(artifact.File)).To(Equal([]byte(content)))
+
+				tmpFiles, err := os.ReadDir(tmpDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tmpFiles).To(HaveLen(1))
+				Expect(filepath.Join(tmpDir, tmpFiles[0].Name())).To(Equal(artifact.File))
+			},
+			Entry("if the first location fails", "/missing.img", "/disk.img", ""),
+			Entry("if the first location serves a different image", "/other.img", "/disk.img", ""),
+			Entry("if the first location serves a broken image", "/broken.img.gz", "/disk.img.gz", "gzip"),
+		)
+
+		DescribeTable("upstreamModificationTime should only use the canonical download URL",
+			func(downloadPath, mirrorPath string, expected time.Time) {
+				artifactInfo := &api.ArtifactDetails{
+					SHA256Sum:   fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+					DownloadURL: server.URL + downloadPath,
+					MirrorURLs:  []string{server.URL + mirrorPath},
+				}
+				files["/mirror.img"] = []byte(content)
+
+				artifact, err := b.getArtifact(artifactInfo)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(b.upstreamModificationTime(artifactInfo, artifact)).To(BeTemporally("==", expected))
+			},
+			Entry("if the canonical download URL served the artifact", "/disk.img", "/mirror.img", lastModified),
+			Entry("if a mirror served the artifact", "/other.img", "/mirror.img", lastModified),
+		)
+
+		It("upstreamModificationTime should fail if a mirror served the artifact and the canonical download URL fails", func() {
+			artifactInfo := &api.ArtifactDetails{DownloadURL: server.URL + "/missing.img"}
+			artifact := &downloadedArtifact{URL: server.URL + "/disk.img"}
+
+			_, err := b.upstreamModificationTime(artifactInfo, artifact)
+			Expect(err).To(MatchError(ContainSubstring("set SOURCE_DATE_EPOCH")))
+
+			Expect(os.Setenv("SOURCE_DATE_EPOCH", "1700000000")).To(Succeed())
+			DeferCleanup(os.Unsetenv, "SOURCE_DATE_EPOCH")
+			_, err = b.upstreamModificationTime(artifactInfo, artifact)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("with aliases", func() {
+		var entry *common.Entry
+
+		BeforeEach(func() {
+			entry = &common.Entry{
+				Artifact: generic.New(&api.ArtifactDetails{}, &api.Metadata{Name: "rhcos", Version: "4.13"}),
+				Aliases:  []string{"4"},
+			}
+		})
+
+		It("prepareTags should move the aliases of the entry", func() {
+			timestamp := time.Date(2023, 4, 18, 12, 0, 0, 0, time.UTC)
+			Expect(prepareTags(timestamp, "quay.io/containerdisks", entry, &api.ArtifactDetails{})).To(Equal([]string{
+				"quay.io/containerdisks/rhcos:4.13-2304181200",
+				"quay.io/containerdisks/rhcos:4.13",
+				"quay.io/containerdisks/rhcos:4",
+			}))
+		})
+
+		DescribeTable("isUpToDate should check the images of the aliases",
+			func(labels map[string]map[string]string, upToDate bool) {
+				b := &buildAndPublish{
+					Ctx:     context.Background(),
+					Log:     logrus.NewEntry(logrus.StandardLogger()),
+					Options: &common.Options{PublishImagesOptions: common.PublishImageOptions{LayerCompression: "gzip"}},
+					Repo:    &fakeRepository{labels: labels},
+				}
+				Expect(b.isUpToDate(entry, build.LabelShaSum, "new")).To(Equal(upToDate))
+			},
+			Entry("when the alias points to the image", map[string]map[string]string{
+				"rhcos:4.13": {build.LabelShaSum: "new"},
+				"rhcos:4":    {build.LabelShaSum: "new"},
+			}, true),
+			Entry("when the alias is still owned by an older version", map[string]map[string]string{
+				"rhcos:4.13": {build.LabelShaSum: "new"},
+				"rhcos:4":    {build.LabelShaSum: "old"},
+			}, false),
+			Entry("when the alias does not exist yet", map[string]map[string]string{
+				"rhcos:4.13": {build.LabelShaSum: "new"},
+			}, false),
+		)
+	})
+})
+
+// fakeRepository serves image labels by image reference.
+type fakeRepository struct {
+	repository.Repository
+	labels map[string]map[string]string
+}
+
+func (f *fakeRepository) ImageMetadata(_ context.Context, imgRef string, _ bool) (*repository.ImageInfo, error) {
+	return &repository.ImageInfo{Labels: f.labels[imgRef]}, nil
+}
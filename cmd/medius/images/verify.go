@@ -27,6 +27,10 @@ import (
 
 const (
 	VerifyUsername = "verify"
+
+	// Names of the checks which run before the tests of an artifact
+	bootTest       = "Boot"
+	preferenceTest = "Preference"
 )
 
 func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
@@ -65,7 +69,7 @@ func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
 				}
 
 				errString := ""
-				err := verifyArtifact(cmd.Context(), e.Artifact, r, options, client)
+				tests, err := verifyArtifact(cmd.Context(), e.Artifact, r, options, client)
 				if err != nil {
 					errString = err.Error()
 				}
@@ -74,6 +78,7 @@ func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
 					Tags:  r.Tags,
 					Stage: StageVerify,
 					Err:   errString,
+					Tests: tests,
 				}, err
 			})
 
@@ -116,77 +121,97 @@ func NewVerifyImagesCommand(options *common.Options) *cobra.Command {
 	return verifyCmd
 }
 
-func verifyArtifact(ctx context.Context, a api.Artifact, res api.ArtifactResult, o *common.Options, client kvirtcli.KubevirtClient) error {
+// verifyArtifact boots a VM from the containerdisk and runs the tests of the artifact on it.
+// It returns the result of every check which ran, even if one of them failed.
+func verifyArtifact(ctx context.Context, a api.Artifact, res api.ArtifactResult, o *common.Options,
+	client kvirtcli.KubevirtClient) (map[string]string, error) {
 	log := common.Logger(a)
+	tests := map[string]string{}
 
 	if len(res.Tags) == 0 {
 		err := errors.New("no containerdisks to verify")
 		log.Error(err)
-		return err
+		return nil, err
 	}
 
 	imgRef := path.Join(o.VerifyImagesOptions.Registry, res.Tags[0])
-	vm, privateKey, err := createVM(a, imgRef)
+	vm, privateKey, err := createVM(ctx, a, imgRef)
 	if err != nil {
 		log.WithError(err).Error("Failed to create VM object")
-		return err
+		return nil, err
 	}
 	if errors.Is(ctx.Err(), context.Canceled) {
-		return ctx.Err()
+		return nil, ctx.Err()
 	}
 
 	vmClient := client.VirtualMachine(o.VerifyImagesOptions.Namespace)
 	log.Info("Creating VM")
-	if vm, err = vmClient.Create(vm); err != nil {
+	if vm, err = vmClient.Create(ctx, vm); err != nil {
 		log.WithError(err).Error("Failed to create VM")
-		return err
+		return nil, err
 	}
 
 	defer func() {
-		if err = vmClient.Delete(vm.Name, &metav1.DeleteOptions{GracePeriodSeconds: pointer.Int64(0)}); err != nil {
+		// Delete the VM even if ctx was canceled
+		if err = vmClient.Delete(context.Background(), vm.Name, &metav1.DeleteOptions{GracePeriodSeconds: pointer.Int64(0)}); err != nil {
 			log.WithError(err).Error("Failed to delete VM")
 		}
 	}()
 
 	if errors.Is(ctx.Err(), context.Canceled) {
-		return ctx.Err()
+		return nil, ctx.Err()
 	}
 
 	log.Info("Waiting for VM to be ready")
 	if err = waitVMReady(ctx, vm.Name, vmClient, o.VerifyImagesOptions.Timeout); err != nil {
 		if errors.Is(ctx.Err(), context.Canceled) {
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
 
 		log.WithError(err).Error("VM not ready")
-		return err
+		tests[bootTest] = api.TestFailed
+		return tests, err
 	}
+	tests[bootTest] = api.TestPassed
+
+	if err = verifyPreference(ctx, vm.Name, a.Metadata(), vmClient); err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, ctx.Err()
+		}
 
-	vmi, err := client.VirtualMachineInstance(o.VerifyImagesOptions.Namespace).Get(vm.Name, &metav1.GetOptions{})
+		log.WithError(err).Error("Failed to verify the inferred preference")
+		tests[preferenceTest] = api.TestFailed
+		return tests, err
+	}
+	tests[preferenceTest] = api.TestPassed
+
+	vmi, err := client.VirtualMachineInstance(o.VerifyImagesOptions.Namespace).Get(ctx, vm.Name, &metav1.GetOptions{})
 	if err != nil {
 		log.WithError(err).Error("Failed to get VMI")
-		return err
+		return tests, err
 	}
 	if errors.Is(ctx.Err(), context.Canceled) {
-		return ctx.Err()
+		return nil, ctx.Err()
 	}
 
 	log.Info("Running tests on VMI")
 	for _, testFn := range a.Tests() {
 		if err = testFn(ctx, vmi, &api.ArtifactTestParams{Username: VerifyUsername, PrivateKey: privateKey}); err != nil {
 			log.WithError(err).Error("Failed to verify containerdisk")
-			return err
+			tests[api.TestName(testFn)] = api.TestFailed
+			return tests, err
 		}
+		tests[api.TestName(testFn)] = api.TestPassed
 		if errors.Is(ctx.Err(), context.Canceled) {
-			return ctx.Err()
+			return nil, ctx.Err()
 		}
 	}
 
 	log.Info("Tests successful")
-	return nil
+	return tests, nil
 }
 
-func createVM(artifact api.Artifact, imgRef string) (*v1.VirtualMachine, ed25519.PrivateKey, error) {
+func createVM(ctx context.Context, artifact api.Artifact, imgRef string) (*v1.VirtualMachine, ed25519.PrivateKey, error) {
 	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
 	if err != nil {
 		return nil, nil, err
@@ -197,19 +222,51 @@ func createVM(artifact api.Artifact, imgRef string) (*v1.VirtualMachine, ed25519
 		return nil, nil, err
 	}
 
-	userData := artifact.UserData(
+	userData, err := api.CreateUserData(ctx, artifact,
 		&docs.UserData{
 			Username:       VerifyUsername,
 			AuthorizedKeys: []string{publicKey},
 		},
 	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating the user data of %q: %v", artifact.Metadata().Describe(), err)
+	}
 
 	name := randName(artifact.Metadata().Name)
-	vm := artifact.VM(name, imgRef, userData)
+	vm, err := api.CreateVM(ctx, artifact, name, imgRef, userData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating the VM of %q: %v", artifact.Metadata().Describe(), err)
+	}
+	if vm == nil || vm.Spec.Template == nil {
+		return nil, nil, fmt.Errorf("artifact %q did not provide a VM template", artifact.Metadata().Describe())
+	}
 	vm.Spec.Template.Spec.TerminationGracePeriodSeconds = pointer.Int64(0)
 	return vm, privateKey, nil
 }
 
+// verifyPreference checks that KubeVirt inferred the default preference of the containerdisk
+// if the VM lets it infer its preference.
+func verifyPreference(ctx context.Context, name string, metadata *api.Metadata, client kvirtcli.VirtualMachineInterface) error {
+	if metadata.DefaultPreference == "" {
+		return nil
+	}
+
+	vm, err := client.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if vm.Spec.Preference == nil {
+		return nil
+	}
+
+	if vm.Spec.Preference.Name != metadata.DefaultPreference {
+		return fmt.Errorf("expected inferred preference %q, got %q", metadata.DefaultPreference, vm.Spec.Preference.Name)
+	}
+
+	return nil
+}
+
 func marshallPublicKey(key *ed25519.PrivateKey) (string, error) {
 	sshKey, err := ssh.NewPublicKey(key.Public())
 	if err != nil {
@@ -226,8 +283,8 @@ func randName(name string) string {
 }
 
 func waitVMReady(ctx context.Context, name string, client kvirtcli.VirtualMachineInterface, timeout int) error {
-	return wait.PollImmediateWithContext(ctx, time.Second, time.Duration(timeout)*time.Second, func(_ context.Context) (bool, error) {
-		vm, err := client.Get(name, &metav1.GetOptions{})
+	return wait.PollImmediateWithContext(ctx, time.Second, time.Duration(timeout)*time.Second, func(ctx context.Context) (bool, error) {
+		vm, err := client.Get(ctx, name, &metav1.GetOptions{})
 
 		if err != nil {
 			return false, err
@@ -4,12 +4,14 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"kubevirt.io/containerdisks/cmd/medius/common"
 	"kubevirt.io/containerdisks/cmd/medius/docs"
 	"kubevirt.io/containerdisks/cmd/medius/images"
+	"kubevirt.io/containerdisks/pkg/http"
 )
 
 func main() {
@@ -19,12 +21,20 @@ func main() {
 			ResultsFile: "results.json",
 			Workers:     1,
 		},
+		HTTPClientOptions: http.ClientOptions{
+			ConnectTimeout: 30 * time.Second,
+			ReadTimeout:    2 * time.Minute,
+			UserAgent:      http.DefaultUserAgent,
+		},
 	}
 
 	rootCmd := &cobra.Command{
 		Use:   "medius",
 		Short: "medius determines if new OS images are released and publishes them as containerdisks",
-		Run:   func(cmd *cobra.Command, args []string) {},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return common.ConfigureNetwork(options)
+		},
+		Run: func(cmd *cobra.Command, args []string) {},
 	}
 
 	imagesCmd := &cobra.Command{
@@ -42,6 +52,7 @@ func main() {
 	rootCmd.AddCommand(imagesCmd)
 	rootCmd.AddCommand(docsCmd)
 
+	imagesCmd.AddCommand(images.NewAuditImagesCommand(options))
 	imagesCmd.AddCommand(images.NewPromoteImagesCommand(options))
 	imagesCmd.AddCommand(images.NewPublishImagesCommand(options))
 	imagesCmd.AddCommand(images.NewVerifyImagesCommand(options))
@@ -51,8 +62,28 @@ func main() {
 		options.AllowInsecureRegistry, "allow connecting to insecure registries")
 	rootCmd.PersistentFlags().BoolVar(&options.DryRun, "dry-run",
 		options.DryRun, "don't publish anything")
+	rootCmd.PersistentFlags().StringArrayVar(&options.ExternalProviders, "external-provider",
+		options.ExternalProviders, "Command line of an external artifact provider, can be repeated")
 	rootCmd.PersistentFlags().StringVar(&options.Focus, "focus",
 		options.Focus, "Focus on a specific containerdisk")
+	rootCmd.PersistentFlags().StringVar(&options.HTTPCacheDir, "http-cache-dir",
+		options.HTTPCacheDir, "Directory to cache upstream metadata in across runs")
+	rootCmd.PersistentFlags().DurationVar(&options.HTTPClientOptions.ConnectTimeout, "http-connect-timeout",
+		options.HTTPClientOptions.ConnectTimeout, "Timeout for establishing HTTP connections")
+	rootCmd.PersistentFlags().DurationVar(&options.HTTPClientOptions.ReadTimeout, "http-read-timeout",
+		options.HTTPClientOptions.ReadTimeout, "Timeout for a single blocking read of an HTTP connection, 0 disables it")
+	rootCmd.PersistentFlags().StringVar(&options.HTTPClientOptions.UserAgent, "http-user-agent",
+		options.HTTPClientOptions.UserAgent, "User agent sent with HTTP requests")
+	rootCmd.PersistentFlags().IntVar(&options.HTTPClientOptions.MaxConnsPerHost, "http-max-conns-per-host",
+		options.HTTPClientOptions.MaxConnsPerHost, "Maximum number of concurrent HTTP connections per host, 0 means no limit")
+	rootCmd.PersistentFlags().StringVar(&options.HTTPClientOptions.Proxy, "http-proxy",
+		options.HTTPClientOptions.Proxy, "URL of the HTTP proxy, the proxy environment variables are used if empty")
+	rootCmd.PersistentFlags().StringArrayVar(&options.HTTPClientOptions.CAFiles, "http-ca-file",
+		options.HTTPClientOptions.CAFiles, "PEM file with additional trusted root CAs, can be repeated")
+	rootCmd.PersistentFlags().StringVar(&options.HTTPClientOptions.ClientCertFile, "http-client-cert",
+		options.HTTPClientOptions.ClientCertFile, "PEM file with a client certificate for mTLS")
+	rootCmd.PersistentFlags().StringVar(&options.HTTPClientOptions.ClientKeyFile, "http-client-key",
+		options.HTTPClientOptions.ClientKeyFile, "PEM file with the key of the client certificate for mTLS")
 	imagesCmd.PersistentFlags().StringVar(&options.ImagesOptions.ResultsFile, "results-file",
 		options.ImagesOptions.ResultsFile, "File to store/read results of operations")
 	imagesCmd.PersistentFlags().IntVar(&options.ImagesOptions.Workers, "workers",
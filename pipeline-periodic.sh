@@ -10,9 +10,13 @@ trap '{ make cluster-down; }' EXIT SIGINT SIGTERM SIGSTOP
 
 registry="$(./hack/kubevirtci.sh registry)"
 kubeconfig="$(./hack/kubevirtci.sh kubeconfig)"
-./bin/medius images push --no-fail --dry-run=false --target-registry=${registry} --insecure-skip-tls --workers 3
+# Ephemeral key pair which carries the signature of the push stage to promote
+keys="$(mktemp -d)"
+openssl ecparam -name prime256v1 -genkey -noout -out "${keys}/cosign.key"
+openssl ec -in "${keys}/cosign.key" -pubout -out "${keys}/cosign.pub"
+./bin/medius images push --no-fail --dry-run=false --target-registry=${registry} --insecure-skip-tls --workers 3 --signing-key="${keys}/cosign.key"
 ./bin/medius images verify --no-fail --dry-run=false --kubeconfig=${kubeconfig} --registry="registry:5000" --insecure-skip-tls --workers 3
-./bin/medius images promote --dry-run=false --source-registry=${registry} --insecure-skip-tls --workers 3
+./bin/medius images promote --dry-run=false --source-registry=${registry} --insecure-skip-tls --workers 3 --verification-key="${keys}/cosign.pub"
 
 if [ -n "${QUAY_OAUTH_TOKEN}" ]; then
     ./bin/medius docs publish --dry-run=false --quay-token-file=${QUAY_OAUTH_TOKEN}
@@ -8,8 +8,12 @@ make cluster-up
 FOCUS=${FOCUS:-cirros:6.1}
 registry="$(./hack/kubevirtci.sh registry)"
 kubeconfig="$(./hack/kubevirtci.sh kubeconfig)"
-./bin/medius images push --force --focus="${FOCUS}" --no-fail --dry-run=false --source-registry="${registry}" --insecure-skip-tls
+# Ephemeral key pair which carries the signature of the push stage to promote
+keys="$(mktemp -d)"
+openssl ecparam -name prime256v1 -genkey -noout -out "${keys}/cosign.key"
+openssl ec -in "${keys}/cosign.key" -pubout -out "${keys}/cosign.pub"
+./bin/medius images push --force --focus="${FOCUS}" --no-fail --dry-run=false --source-registry="${registry}" --insecure-skip-tls --signing-key="${keys}/cosign.key"
 ./bin/medius images verify --focus="${FOCUS}" --no-fail --dry-run=false --kubeconfig="${kubeconfig}" --registry="registry:5000" --insecure-skip-tls
-./bin/medius images promote --focus="${FOCUS}" --dry-run=true --source-registry="${registry}" --insecure-skip-tls
+./bin/medius images promote --focus="${FOCUS}" --dry-run=true --source-registry="${registry}" --insecure-skip-tls --verification-key="${keys}/cosign.pub"
 
 make cluster-down
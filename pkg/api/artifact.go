@@ -3,6 +3,9 @@ package api
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
 
 	v1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/containerdisks/pkg/docs"
@@ -17,6 +20,17 @@ type ArtifactTestParams struct {
 	PrivateKey interface{}
 }
 
+const (
+	TestPassed = "passed"
+	TestFailed = "failed"
+)
+
+// TestName returns the name of the function implementing test, for example "SSH".
+func TestName(test ArtifactTest) string {
+	name := runtime.FuncForPC(reflect.ValueOf(test).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
 type ArtifactResult struct {
 	// Tags contains all tags the built containerdisk was tagged with.
 	Tags []string `json:",omitempty"`
@@ -24,20 +38,40 @@ type ArtifactResult struct {
 	Stage string
 	// Err indicates if an error happened while creating, verifying or promoting a containerdisk.
 	Err string `json:",omitempty"`
+	// Tests maps the checks of the verify stage to their result, TestPassed or TestFailed.
+	// Checks which did not run because an earlier one failed are missing.
+	Tests map[string]string `json:",omitempty"`
 }
 
 type ArtifactDetails struct {
 	// SHA256Sum is the checksum of the image to download.
 	SHA256Sum string
+	// SHA512Sum is the SHA512 checksum of the image to download. It only needs to be set
+	// if the upstream does not publish SHA256 checksums, in which case SHA256Sum stays empty.
+	SHA512Sum string
+	// ChecksumURL points to the upstream file the checksum was read from.
+	ChecksumURL string `json:",omitempty"`
 	// DownloadURL points to the target image.
 	DownloadURL string
+	// MirrorURLs point to identical copies of the target image. They are tried in order
+	// if the download from DownloadURL fails.
+	MirrorURLs []string `json:",omitempty"`
 	// Compression describes the compression format of the downloaded image.
-	// Supported are "" (none), "gzip" and "xz".
+	// Supported are "" (none), "gzip", "xz", "bzip2" and "zstd".
 	Compression string
 	// AdditionalUniqueTags describes additional tags which furter specify the downloaded
 	// artifact version. For instance the main moving tag for fedora 35 would be '35' and here additional tags
 	// like '35-1.2'. This is useful for people to easier cross-reference the sources.
 	AdditionalUniqueTags []string
+	// MovingTags lists the tags out of AdditionalUniqueTags which may point to a new upstream
+	// image, like release codenames. All other additional tags must always point to the same
+	// upstream image, a changed checksum for them is treated as an error.
+	MovingTags []string `json:",omitempty"`
+}
+
+// DownloadURLs returns DownloadURL followed by MirrorURLs.
+func (d *ArtifactDetails) DownloadURLs() []string {
+	return append([]string{d.DownloadURL}, d.MirrorURLs...)
 }
 
 type Metadata struct {
@@ -47,8 +81,18 @@ type Metadata struct {
 	Version string
 	// Description of the project in Markdown format.
 	Description string
+	// URL points to the homepage of the project.
+	URL string `json:",omitempty"`
+	// Licenses is an SPDX license expression of the image content, it is omitted if unknown.
+	// Distributions which consist of packages under many licenses use a LicenseRef to their
+	// licensing documentation, like LicenseRef-Fedora.
+	Licenses string `json:",omitempty"`
 	// CloudInit/Ignition Payload example.
 	ExampleUserDataPayload string
+	// DefaultInstancetype is the name of the KubeVirt cluster instancetype VMs booting the image should use.
+	DefaultInstancetype string `json:",omitempty"`
+	// DefaultPreference is the name of the KubeVirt cluster preference matching the guest OS of the image.
+	DefaultPreference string `json:",omitempty"`
 }
 
 func (m Metadata) Describe() string {
@@ -56,15 +100,43 @@ func (m Metadata) Describe() string {
 }
 
 type Artifact interface {
-	Inspect() (*ArtifactDetails, error)
+	// Inspect must stop all upstream requests when ctx is done.
+	Inspect(ctx context.Context) (*ArtifactDetails, error)
 	Metadata() *Metadata
 	VM(name, imgRef, userData string) *v1.VirtualMachine
 	UserData(data *docs.UserData) string
 	Tests() []ArtifactTest
 }
 
+// FallibleArtifact is implemented by artifacts whose VM and user data creation can fail,
+// like external providers. VM and UserData of these artifacts only log the error.
+type FallibleArtifact interface {
+	Artifact
+	CreateVM(ctx context.Context, name, imgRef, userData string) (*v1.VirtualMachine, error)
+	CreateUserData(ctx context.Context, data *docs.UserData) (string, error)
+}
+
+// CreateVM returns the VirtualMachine of artifact and the error of a FallibleArtifact.
+func CreateVM(ctx context.Context, artifact Artifact, name, imgRef, userData string) (*v1.VirtualMachine, error) {
+	if fallible, ok := artifact.(FallibleArtifact); ok {
+		return fallible.CreateVM(ctx, name, imgRef, userData)
+	}
+
+	return artifact.VM(name, imgRef, userData), nil
+}
+
+// CreateUserData returns the user data of artifact and the error of a FallibleArtifact.
+func CreateUserData(ctx context.Context, artifact Artifact, data *docs.UserData) (string, error) {
+	if fallible, ok := artifact.(FallibleArtifact); ok {
+		return fallible.CreateUserData(ctx, data)
+	}
+
+	return artifact.UserData(data), nil
+}
+
 type ArtifactsGatherer interface {
 	// Gather must return a sorted list of dynamically gathered artifacts.
 	// Artifacts have to be sorted in descending order with the latest release coming first.
-	Gather() ([]Artifact, error)
+	// Gather must stop all upstream requests when ctx is done.
+	Gather(ctx context.Context) ([]Artifact, error)
 }
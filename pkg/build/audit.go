@@ -0,0 +1,125 @@
+package build
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/containerd/stargz-snapshotter/estargz/zstdchunked"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// AuditResult is the outcome of auditing a containerdisk image.
+type AuditResult struct {
+	// DiskSHA256Sum is the SHA256 checksum of the disk image in the layer.
+	DiskSHA256Sum string
+	// DiskSHA512Sum is the SHA512 checksum of the disk image in the layer.
+	DiskSHA512Sum string
+	// Drift lists all deviations from the layout written by ContainerDisk.
+	Drift []string
+}
+
+// Audit reads the layer of a containerdisk, computes the checksums of its disk image
+// and checks that its layout matches the layout written by ContainerDisk.
+func Audit(img v1.Image) (*AuditResult, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the image layers: %v", err)
+	}
+	if len(layers) != 1 {
+		return &AuditResult{Drift: []string{fmt.Sprintf("image has %d layers, expected 1", len(layers))}}, nil
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("error getting the image manifest: %v", err)
+	}
+
+	reader, err := layers[0].Uncompressed()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the image layer: %v", err)
+	}
+	defer reader.Close()
+
+	return auditLayer(reader, isChunked(manifest.Layers[0].Annotations))
+}
+
+// isChunked returns true if the annotations of a layer descriptor belong to an eStargz
+// or zstd:chunked layer.
+func isChunked(annotations map[string]string) bool {
+	_, estargzTOC := annotations[estargz.TOCJSONDigestAnnotation]
+	_, zstdChunkedTOC := annotations[zstdchunked.ManifestChecksumAnnotation]
+	return estargzTOC || zstdChunkedTOC
+}
+
+// auditLayer checks the tar stream of a layer. Chunked layers may contain the landmark
+// and table of contents entries of eStargz in addition.
+func auditLayer(reader io.Reader, chunked bool) (*AuditResult, error) {
+	result := &AuditResult{}
+	driftf := func(format string, args ...interface{}) {
+		result.Drift = append(result.Drift, fmt.Sprintf(format, args...))
+	}
+
+	seenDir, seenImage := false, false
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading the tar layer: %v", err)
+		}
+
+		switch header.Name {
+		case DiskDir:
+			seenDir = true
+			checkHeader(header, tar.TypeDir, DiskDirMode, driftf)
+		case DiskImagePath:
+			if !seenDir {
+				driftf("%s precedes %s", DiskImagePath, DiskDir)
+			}
+			seenImage = true
+			checkHeader(header, tar.TypeReg, DiskImageMode, driftf)
+
+			sha256Hash, sha512Hash := sha256.New(), sha512.New()
+			if _, err := io.Copy(io.MultiWriter(sha256Hash, sha512Hash), tarReader); err != nil {
+				return nil, fmt.Errorf("error reading %s: %v", DiskImagePath, err)
+			}
+			result.DiskSHA256Sum = hex.EncodeToString(sha256Hash.Sum(nil))
+			result.DiskSHA512Sum = hex.EncodeToString(sha512Hash.Sum(nil))
+		case estargz.PrefetchLandmark, estargz.NoPrefetchLandmark, estargz.TOCTarName:
+			if !chunked {
+				driftf("unexpected entry %s", header.Name)
+			}
+		default:
+			driftf("unexpected entry %s", header.Name)
+		}
+	}
+
+	if !seenDir {
+		driftf("missing %s", DiskDir)
+	}
+	if !seenImage {
+		driftf("missing %s", DiskImagePath)
+	}
+
+	return result, nil
+}
+
+func checkHeader(header *tar.Header, typeflag byte, mode int64, driftf func(format string, args ...interface{})) {
+	if header.Typeflag != typeflag {
+		driftf("%s: type %q, expected %q", header.Name, header.Typeflag, typeflag)
+	}
+	if header.Mode&07777 != mode {
+		driftf("%s: mode %#o, expected %#o", header.Name, header.Mode&07777, mode)
+	}
+	if header.Uid != QemuID || header.Gid != QemuID {
+		driftf("%s: owner %d:%d, expected %d:%d", header.Name, header.Uid, header.Gid, QemuID, QemuID)
+	}
+}
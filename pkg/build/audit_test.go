@@ -0,0 +1,102 @@
+package build
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Audit", func() {
+	const imageContent = "hello"
+
+	checksum := func(data string) string {
+		sum := sha256.Sum256([]byte(data))
+		return hex.EncodeToString(sum[:])
+	}
+
+	DescribeTable("should accept a containerdisk built by ContainerDisk", func(compression Compression) {
+		imageName := filepath.Join(GinkgoT().TempDir(), "image")
+		Expect(os.WriteFile(imageName, []byte(imageContent), 0600)).To(Succeed())
+
+		img, err := ContainerDisk(imageName, checksum(imageContent), time.Unix(0, 0), WithLayerCompression(compression))
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := Audit(img)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Drift).To(BeEmpty())
+		Expect(result.DiskSHA256Sum).To(Equal(checksum(imageContent)))
+		Expect(result.DiskSHA512Sum).To(HaveLen(128))
+	},
+		Entry("with a gzip layer", CompressionGzip),
+		Entry("with a zstd layer", CompressionZstd),
+		Entry("with an uncompressed layer", CompressionUncompressed),
+		Entry("with an eStargz layer", CompressionEstargz),
+		Entry("with a zstd:chunked layer", CompressionZstdChunked),
+	)
+
+	DescribeTable("should report drift of the layer layout", func(headers []tar.Header, expected []string) {
+		var buf bytes.Buffer
+		tarWriter := tar.NewWriter(&buf)
+		for i := range headers {
+			Expect(tarWriter.WriteHeader(&headers[i])).To(Succeed())
+			if headers[i].Size > 0 {
+				_, err := io.WriteString(tarWriter, imageContent)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		}
+		Expect(tarWriter.Close()).To(Succeed())
+
+		result, err := auditLayer(&buf, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Drift).To(Equal(expected))
+	},
+		Entry("with wrong modes and owners",
+			[]tar.Header{
+				{Typeflag: tar.TypeDir, Name: DiskDir, Mode: 0755, Uid: QemuID, Gid: QemuID},
+				{Typeflag: tar.TypeReg, Name: DiskImagePath, Mode: 0644, Uid: 0, Gid: 0, Size: int64(len(imageContent))},
+			},
+			[]string{
+				"disk/: mode 0755, expected 0555",
+				"disk/disk.img: mode 0644, expected 0444",
+				"disk/disk.img: owner 0:0, expected 107:107",
+			},
+		),
+		Entry("with additional and missing entries",
+			[]tar.Header{
+				{Typeflag: tar.TypeDir, Name: DiskDir, Mode: DiskDirMode, Uid: QemuID, Gid: QemuID},
+				{Typeflag: tar.TypeSymlink, Name: "disk/other.img", Linkname: "/etc/passwd"},
+			},
+			[]string{
+				"unexpected entry disk/other.img",
+				"missing disk/disk.img",
+			},
+		),
+		Entry("with eStargz entries in a layer which is not chunked",
+			[]tar.Header{
+				{Typeflag: tar.TypeReg, Name: ".no.prefetch.landmark", Size: int64(len(imageContent))},
+				{Typeflag: tar.TypeDir, Name: DiskDir, Mode: DiskDirMode, Uid: QemuID, Gid: QemuID},
+				{Typeflag: tar.TypeReg, Name: DiskImagePath, Mode: DiskImageMode, Uid: QemuID, Gid: QemuID, Size: int64(len(imageContent))},
+			},
+			[]string{
+				"unexpected entry .no.prefetch.landmark",
+			},
+		),
+		Entry("with a disk image which is not a regular file",
+			[]tar.Header{
+				{Typeflag: tar.TypeDir, Name: DiskDir, Mode: DiskDirMode, Uid: QemuID, Gid: QemuID},
+				{Typeflag: tar.TypeSymlink, Name: DiskImagePath, Linkname: "/dev/sda", Mode: DiskImageMode, Uid: QemuID, Gid: QemuID},
+			},
+			[]string{
+				`disk/disk.img: type '2', expected '0'`,
+			},
+		),
+	)
+})
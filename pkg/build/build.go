@@ -2,26 +2,109 @@ package build
 
 import (
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
-	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
 const (
-	LabelShaSum       = "shasum"
-	ImageArchitecture = "amd64"
+	LabelShaSum           = "shasum"
+	LabelSha512Sum        = "sha512sum"
+	LabelDiskFormat       = "diskformat"
+	LabelVirtualSize      = "virtualsize"
+	LabelDiskShaSum       = "diskshasum"
+	LabelDownloadURL      = "downloadurl"
+	LabelUniqueTags       = "uniquetags"
+	LabelLayerCompression = "layercompression"
+	ImageArchitecture     = "amd64"
 )
 
-func ContainerDisk(imgPath string, checksum string) (v1.Image, error) {
-	img := empty.Image
-	layer, err := tarball.LayerFromOpener(StreamLayerOpener(imgPath))
+// Keys of the labels KubeVirt infers the default instancetype and preference of a VM from.
+const (
+	LabelDefaultInstancetype = "instancetype.kubevirt.io/default-instancetype"
+	LabelDefaultPreference   = "instancetype.kubevirt.io/default-preference"
+)
+
+// Keys of the pre-defined annotations of the OCI image spec. Labels with these keys are
+// also set as annotations of the image manifest.
+const (
+	AnnotationCreated     = "org.opencontainers.image.created"
+	AnnotationURL         = "org.opencontainers.image.url"
+	AnnotationSource      = "org.opencontainers.image.source"
+	AnnotationVersion     = "org.opencontainers.image.version"
+	AnnotationRevision    = "org.opencontainers.image.revision"
+	AnnotationLicenses    = "org.opencontainers.image.licenses"
+	AnnotationTitle       = "org.opencontainers.image.title"
+	AnnotationDescription = "org.opencontainers.image.description"
+
+	annotationPrefix = "org.opencontainers.image."
+)
+
+type Option func(cf *v1.ConfigFile)
+
+// WithLabel adds an additional label to the image config.
+func WithLabel(key, value string) Option {
+	return func(cf *v1.ConfigFile) {
+		cf.Config.Labels[key] = value
+	}
+}
+
+// Image is a containerdisk built by ContainerDisk. Close releases the temporary file
+// which chunked layers are served from, the image can't be read afterwards.
+type Image struct {
+	v1.Image
+	layer v1.Layer
+}
+
+func (i *Image) Close() error {
+	if closer, ok := i.layer.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// ContainerDisk builds a containerdisk from the disk image at imgPath. The image is
+// reproducible, all timestamps in it are set to timestamp. The layer is compressed with
+// gzip unless another compression is set with WithLayerCompression. The image has to be
+// closed once it is pushed.
+func ContainerDisk(imgPath, checksum string, timestamp time.Time, opts ...Option) (*Image, error) {
+	timestamp = timestamp.UTC().Truncate(time.Second)
+
+	config := &v1.ConfigFile{Config: v1.Config{Labels: map[string]string{
+		LabelShaSum:           checksum,
+		AnnotationCreated:     timestamp.Format(time.RFC3339),
+		LabelLayerCompression: string(CompressionGzip),
+	}}}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	// Annotations are only defined for OCI manifests
+	img := mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON)
+	layer, layerAnnotations, err := newLayer(imgPath, timestamp, LayerCompression(config.Config.Labels))
 	if err != nil {
 		return nil, fmt.Errorf("error creating an image layer from disk: %v", err)
 	}
 
-	img, err = mutate.AppendLayers(img, layer)
+	containerDisk := &Image{layer: layer}
+	// Only a successfully built image is handed over to the caller to be closed
+	defer func() {
+		if containerDisk.Image == nil {
+			containerDisk.Close()
+		}
+	}()
+
+	img, err = mutate.Append(img, mutate.Addendum{
+		Layer:       layer,
+		History:     v1.History{Created: v1.Time{Time: timestamp}},
+		Annotations: layerAnnotations,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("error appending the image layer: %v", err)
 	}
@@ -33,12 +116,21 @@ func ContainerDisk(imgPath string, checksum string) (v1.Image, error) {
 
 	// Modify the config file
 	cf.Architecture = ImageArchitecture
-	cf.Config = v1.Config{Labels: map[string]string{LabelShaSum: checksum}}
+	cf.Created = v1.Time{Time: timestamp}
+	cf.Config = config.Config
 
 	img, err = mutate.ConfigFile(img, cf)
 	if err != nil {
 		return nil, fmt.Errorf("error setting the image config file: %v", err)
 	}
 
-	return img, nil
+	annotations := map[string]string{}
+	for key, value := range cf.Config.Labels {
+		if strings.HasPrefix(key, annotationPrefix) {
+			annotations[key] = value
+		}
+	}
+
+	containerDisk.Image = mutate.Annotations(img, annotations).(v1.Image)
+	return containerDisk, nil
 }
@@ -0,0 +1,183 @@
+package build
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/containerd/stargz-snapshotter/estargz/zstdchunked"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContainerDisk", func() {
+	var imageName string
+
+	BeforeEach(func() {
+		imageName = filepath.Join(GinkgoT().TempDir(), "image")
+		Expect(os.WriteFile(imageName, []byte("hello"), 0600)).To(Succeed())
+	})
+
+	build := func(timestamp time.Time) string {
+		img, err := ContainerDisk(imageName, "checksum", timestamp, WithLabel(LabelDiskFormat, "raw"))
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		return digest.String()
+	}
+
+	It("should be reproducible", func() {
+		timestamp := time.Date(2023, 4, 18, 12, 0, 0, 0, time.UTC)
+		first := build(timestamp)
+
+		// The modification time of the file must not matter
+		Expect(os.Chtimes(imageName, time.Now().Add(time.Hour), time.Now().Add(time.Hour))).To(Succeed())
+		Expect(build(timestamp)).To(Equal(first))
+		Expect(build(timestamp.Add(500 * time.Millisecond))).To(Equal(first))
+
+		Expect(build(timestamp.Add(time.Hour))).ToNot(Equal(first))
+	})
+
+	It("should set the OCI labels as manifest annotations", func() {
+		timestamp := time.Date(2023, 4, 18, 12, 0, 0, 0, time.UTC)
+		img, err := ContainerDisk(imageName, "checksum", timestamp,
+			WithLabel(AnnotationTitle, "fedora"), WithLabel(LabelDiskFormat, "raw"))
+		Expect(err).ToNot(HaveOccurred())
+
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.MediaType).To(Equal(types.OCIManifestSchema1))
+		Expect(manifest.Config.MediaType).To(Equal(types.OCIConfigJSON))
+		Expect(manifest.Layers[0].MediaType).To(Equal(types.OCILayer))
+		Expect(manifest.Annotations).To(Equal(map[string]string{
+			AnnotationTitle:   "fedora",
+			AnnotationCreated: "2023-04-18T12:00:00Z",
+		}))
+
+		cf, err := img.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(LabelDiskFormat, "raw"))
+		Expect(cf.Config.Labels).To(HaveKeyWithValue(AnnotationCreated, "2023-04-18T12:00:00Z"))
+	})
+
+	It("should set the creation time", func() {
+		timestamp := time.Date(2023, 4, 18, 12, 0, 0, 0, time.UTC)
+		img, err := ContainerDisk(imageName, "checksum", timestamp)
+		Expect(err).ToNot(HaveOccurred())
+
+		cf, err := img.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cf.Created.Time).To(BeTemporally("==", timestamp))
+		Expect(cf.History).To(HaveLen(1))
+		Expect(cf.History[0].Created.Time).To(BeTemporally("==", timestamp))
+	})
+
+	DescribeTable("should record the layer compression", func(compression Compression, mediaType types.MediaType,
+		annotations []string) {
+		timestamp := time.Date(2023, 4, 18, 12, 0, 0, 0, time.UTC)
+		img, err := ContainerDisk(imageName, "checksum", timestamp, WithLayerCompression(compression))
+		Expect(err).ToNot(HaveOccurred())
+		defer img.Close()
+
+		cf, err := img.ConfigFile()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(LayerCompression(cf.Config.Labels)).To(Equal(compression))
+
+		manifest, err := img.Manifest()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Layers[0].MediaType).To(Equal(mediaType))
+		Expect(manifest.Layers[0].Annotations).To(HaveLen(len(annotations)))
+		for _, annotation := range annotations {
+			Expect(manifest.Layers[0].Annotations).To(HaveKey(annotation))
+		}
+
+		digest, err := img.Digest()
+		Expect(err).ToNot(HaveOccurred())
+		rebuilt, err := ContainerDisk(imageName, "checksum", timestamp, WithLayerCompression(compression))
+		Expect(err).ToNot(HaveOccurred())
+		defer rebuilt.Close()
+		Expect(rebuilt.Digest()).To(Equal(digest))
+	},
+		Entry("with gzip", CompressionGzip, types.OCILayer, nil),
+		Entry("with zstd", CompressionZstd, types.OCILayerZStd, nil),
+		Entry("uncompressed", CompressionUncompressed, types.OCIUncompressedLayer, nil),
+		Entry("with eStargz", CompressionEstargz, types.OCILayer, []string{estargz.TOCJSONDigestAnnotation}),
+		Entry("with zstd:chunked", CompressionZstdChunked, types.OCILayerZStd,
+			[]string{zstdchunked.ManifestChecksumAnnotation, zstdchunked.ManifestPositionAnnotation}),
+	)
+
+	It("should build eStargz layers which can be opened lazily", func() {
+		img, err := ContainerDisk(imageName, "checksum", time.Unix(0, 0), WithLayerCompression(CompressionEstargz))
+		Expect(err).ToNot(HaveOccurred())
+		defer img.Close()
+
+		layers, err := img.Layers()
+		Expect(err).ToNot(HaveOccurred())
+		reader, err := layers[0].Compressed()
+		Expect(err).ToNot(HaveOccurred())
+		defer reader.Close()
+		blob, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		stargz, err := estargz.Open(io.NewSectionReader(bytes.NewReader(blob), 0, int64(len(blob))))
+		Expect(err).ToNot(HaveOccurred())
+		entry, exists := stargz.Lookup(DiskImagePath)
+		Expect(exists).To(BeTrue())
+		Expect(entry.Size).To(Equal(int64(len("hello"))))
+	})
+
+	DescribeTable("should serve chunked layers until they are closed",
+		func(compression Compression) {
+			img, err := ContainerDisk(imageName, "checksum", time.Unix(0, 0), WithLayerCompression(compression))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Remove(imageName)).To(Succeed())
+
+			layers, err := img.Layers()
+			Expect(err).ToNot(HaveOccurred())
+			for i := 0; i < 2; i++ {
+				digest, size, err := computeHash(layers[0].Compressed)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(layers[0].Digest()).To(Equal(digest))
+				Expect(layers[0].Size()).To(Equal(size))
+
+				diffID, _, err := computeHash(layers[0].Uncompressed)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(layers[0].DiffID()).To(Equal(diffID))
+			}
+
+			Expect(img.Close()).To(Succeed())
+			_, _, err = computeHash(layers[0].Compressed)
+			Expect(err).To(MatchError(os.ErrClosed))
+		},
+		Entry("with eStargz", CompressionEstargz),
+		Entry("with zstd:chunked", CompressionZstdChunked),
+	)
+
+	DescribeTable("estargzFooter should be understood by the estargz package",
+		func(tocOffset int64) {
+			footer := estargzFooter(tocOffset)
+			Expect(footer).To(HaveLen(estargz.FooterSize))
+
+			_, parsedOffset, _, err := (&estargz.GzipDecompressor{}).ParseFooter(footer)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedOffset).To(Equal(tocOffset))
+
+			parsedOffset, footerSize, err := estargz.OpenFooter(io.NewSectionReader(bytes.NewReader(footer), 0, int64(len(footer))))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(parsedOffset).To(Equal(tocOffset))
+			Expect(footerSize).To(BeEquivalentTo(estargz.FooterSize))
+		},
+		Entry("at the start of the blob", int64(0)),
+		Entry("within the blob", int64(4096)),
+		Entry("at a large offset", int64(1)<<40),
+	)
+
+	It("should reject unknown layer compressions", func() {
+		_, err := ContainerDisk(imageName, "checksum", time.Unix(0, 0), WithLayerCompression("lz4"))
+		Expect(err).To(MatchError(ContainSubstring(`unsupported layer compression "lz4"`)))
+	})
+})
@@ -0,0 +1,310 @@
+package build
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/containerd/stargz-snapshotter/estargz"
+	"github.com/containerd/stargz-snapshotter/estargz/zstdchunked"
+	ggcrcompression "github.com/google/go-containerregistry/pkg/compression"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+)
+
+// Compression is the compression of the containerdisk layer.
+type Compression string
+
+const (
+	CompressionGzip         Compression = "gzip"
+	CompressionZstd         Compression = "zstd"
+	CompressionUncompressed Compression = "uncompressed"
+	// CompressionEstargz is a gzip compatible layer which runtimes supporting eStargz can pull lazily.
+	CompressionEstargz Compression = "estargz"
+	// CompressionZstdChunked is a zstd compatible layer which runtimes supporting zstd:chunked can pull lazily.
+	CompressionZstdChunked Compression = "zstd:chunked"
+)
+
+// Compressions lists all supported layer compressions.
+var Compressions = []Compression{
+	CompressionGzip, CompressionZstd, CompressionUncompressed, CompressionEstargz, CompressionZstdChunked,
+}
+
+// ParseCompression returns the Compression named s.
+func ParseCompression(s string) (Compression, error) {
+	for _, compression := range Compressions {
+		if string(compression) == s {
+			return compression, nil
+		}
+	}
+
+	names := make([]string, 0, len(Compressions))
+	for _, compression := range Compressions {
+		names = append(names, string(compression))
+	}
+	return "", fmt.Errorf("unsupported layer compression %q, supported are %s", s, strings.Join(names, ", "))
+}
+
+// WithLayerCompression sets the compression of the layer and records it in a label.
+func WithLayerCompression(compression Compression) Option {
+	return WithLabel(LabelLayerCompression, string(compression))
+}
+
+// LayerCompression returns the compression recorded in the labels of a containerdisk.
+// Containerdisks without the label have a gzip compressed layer.
+func LayerCompression(labels map[string]string) Compression {
+	if compression, exists := labels[LabelLayerCompression]; exists {
+		return Compression(compression)
+	}
+	return CompressionGzip
+}
+
+// newLayer creates the layer with the disk image at imgPath and returns the annotations
+// which belong to its descriptor in the image manifest.
+func newLayer(imgPath string, modTime time.Time, compression Compression) (v1.Layer, map[string]string, error) {
+	switch compression {
+	case CompressionGzip:
+		layer, err := tarball.LayerFromOpener(StreamLayerOpener(imgPath, modTime), tarball.WithMediaType(types.OCILayer))
+		return layer, nil, err
+	case CompressionZstd:
+		layer, err := tarball.LayerFromOpener(StreamLayerOpener(imgPath, modTime),
+			tarball.WithCompression(ggcrcompression.ZStd), tarball.WithMediaType(types.OCILayerZStd))
+		return layer, nil, err
+	case CompressionUncompressed:
+		layer, err := newUncompressedLayer(StreamLayerOpener(imgPath, modTime))
+		return layer, nil, err
+	case CompressionEstargz:
+		return newChunkedLayer(imgPath, modTime, types.OCILayer, estargz.TOCJSONDigestAnnotation, func(map[string]string) estargz.Compression {
+			return &chunkedCompression{&estargzCompressor{estargz.NewGzipCompressorWithLevel(gzip.BestSpeed)}, &estargz.GzipDecompressor{}}
+		})
+	case CompressionZstdChunked:
+		return newChunkedLayer(imgPath, modTime, types.OCILayerZStd, "", func(metadata map[string]string) estargz.Compression {
+			compressor := &zstdchunked.Compressor{CompressionLevel: zstd.SpeedFastest, Metadata: metadata}
+			return &chunkedCompression{compressor, &zstdchunked.Decompressor{}}
+		})
+	default:
+		return nil, nil, fmt.Errorf("unsupported layer compression %q", compression)
+	}
+}
+
+// newChunkedLayer creates a layer in which the disk image is split into individually compressed
+// chunks and a table of contents, which allows runtimes to pull it lazily. The layer is built
+// once into an unlinked temporary file which it is served from, the disk space is freed when
+// the layer is closed. newCompression may add annotations to metadata, tocAnnotation is set to
+// the digest of the table of contents if not empty.
+func newChunkedLayer(imgPath string, modTime time.Time, mediaType types.MediaType, tocAnnotation string,
+	newCompression func(metadata map[string]string) estargz.Compression) (v1.Layer, map[string]string, error) {
+	tarReader, file, err := layerSectionReader(imgPath, modTime)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	annotations := map[string]string{}
+	blob, err := estargz.Build(tarReader, estargz.WithCompression(newCompression(annotations)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error building the chunked layer: %w", err)
+	}
+	defer blob.Close()
+	if tocAnnotation != "" {
+		annotations[tocAnnotation] = blob.TOCDigest().String()
+	}
+
+	blobFile, err := os.CreateTemp("", "containerdisk-layer")
+	if err != nil {
+		return nil, nil, err
+	}
+	// The open file keeps its content until the layer is closed
+	if err = os.Remove(blobFile.Name()); err != nil {
+		blobFile.Close()
+		return nil, nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(blobFile, hash), blob)
+	if err != nil {
+		blobFile.Close()
+		return nil, nil, fmt.Errorf("error writing the chunked layer: %w", err)
+	}
+	diffID, err := v1.NewHash(blob.DiffID().String())
+	if err != nil {
+		blobFile.Close()
+		return nil, nil, fmt.Errorf("error computing the layer diffID: %w", err)
+	}
+
+	layer := &staticLayer{
+		compressed: func() (io.ReadCloser, error) {
+			return io.NopCloser(io.NewSectionReader(blobFile, 0, size)), nil
+		},
+		uncompressed: func() (io.ReadCloser, error) {
+			return newCompression(nil).Reader(io.NewSectionReader(blobFile, 0, size))
+		},
+		digest:    v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(hash.Sum(nil))},
+		diffID:    diffID,
+		size:      size,
+		mediaType: mediaType,
+		file:      blobFile,
+	}
+
+	return layer, annotations, nil
+}
+
+// chunkedCompression combines the compressor and decompressor of a chunked layer format.
+type chunkedCompression struct {
+	estargz.Compressor
+	estargz.Decompressor
+}
+
+// estargzCompressor writes the eStargz footer byte by byte. The footer of the estargz package
+// relies on the deflate output for empty input, which differs between Go releases.
+type estargzCompressor struct {
+	*estargz.GzipCompressor
+}
+
+func (c *estargzCompressor) WriteTOCAndFooter(w io.Writer, off int64, toc *estargz.JTOC, diffHash hash.Hash) (digest.Digest, error) {
+	tocJSON, err := json.MarshalIndent(toc, "", "\t")
+	if err != nil {
+		return "", err
+	}
+
+	gzipWriter, err := c.Writer(w)
+	if err != nil {
+		return "", err
+	}
+	writer := io.Writer(gzipWriter)
+	if diffHash != nil {
+		writer = io.MultiWriter(gzipWriter, diffHash)
+	}
+	tarWriter := tar.NewWriter(writer)
+	if err = tarWriter.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     estargz.TOCTarName,
+		Size:     int64(len(tocJSON)),
+	}); err != nil {
+		return "", err
+	}
+	if _, err = tarWriter.Write(tocJSON); err != nil {
+		return "", err
+	}
+	if err = tarWriter.Close(); err != nil {
+		return "", err
+	}
+	if err = gzipWriter.Close(); err != nil {
+		return "", err
+	}
+
+	if _, err = w.Write(estargzFooter(off)); err != nil {
+		return "", err
+	}
+	return digest.FromBytes(tocJSON), nil
+}
+
+// estargzFooter returns an empty gzip member whose extra field points to the table of contents.
+func estargzFooter(tocOffset int64) []byte {
+	const (
+		gzipID1, gzipID2 = 0x1f, 0x8b
+		deflate          = 8
+		flagExtra        = 4
+		osUnknown        = 255
+	)
+	subfield := fmt.Sprintf("%016xSTARGZ", tocOffset)
+
+	footer := []byte{gzipID1, gzipID2, deflate, flagExtra, 0, 0, 0, 0, 0, osUnknown}
+	footer = binary.LittleEndian.AppendUint16(footer, uint16(4+len(subfield)))
+	footer = append(footer, 'S', 'G')
+	footer = binary.LittleEndian.AppendUint16(footer, uint16(len(subfield)))
+	footer = append(footer, subfield...)
+	// A final empty stored deflate block, followed by the CRC-32 and the size of the empty content
+	footer = append(footer, 1, 0, 0, 0xff, 0xff)
+	return append(footer, 0, 0, 0, 0, 0, 0, 0, 0)
+}
+
+// staticLayer is a layer whose digests are computed once when it is created, its content
+// is opened again whenever it is read.
+type staticLayer struct {
+	compressed   tarball.Opener
+	uncompressed tarball.Opener
+	digest       v1.Hash
+	diffID       v1.Hash
+	size         int64
+	mediaType    types.MediaType
+	// file is the temporary file the layer is served from, if any. It is closed by Close.
+	file *os.File
+}
+
+// newUncompressedLayer creates a layer which is stored as plain tar.
+func newUncompressedLayer(opener tarball.Opener) (*staticLayer, error) {
+	layer := &staticLayer{
+		compressed:   opener,
+		uncompressed: opener,
+		mediaType:    types.OCIUncompressedLayer,
+	}
+
+	var err error
+	if layer.digest, layer.size, err = computeHash(opener); err != nil {
+		return nil, fmt.Errorf("error computing the layer digest: %w", err)
+	}
+	layer.diffID = layer.digest
+
+	return layer, nil
+}
+
+func (l *staticLayer) Digest() (v1.Hash, error) {
+	return l.digest, nil
+}
+
+func (l *staticLayer) DiffID() (v1.Hash, error) {
+	return l.diffID, nil
+}
+
+func (l *staticLayer) Compressed() (io.ReadCloser, error) {
+	return l.compressed()
+}
+
+func (l *staticLayer) Uncompressed() (io.ReadCloser, error) {
+	return l.uncompressed()
+}
+
+func (l *staticLayer) Size() (int64, error) {
+	return l.size, nil
+}
+
+func (l *staticLayer) MediaType() (types.MediaType, error) {
+	return l.mediaType, nil
+}
+
+func (l *staticLayer) Close() error {
+	if l.file == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
+
+func computeHash(opener tarball.Opener) (v1.Hash, int64, error) {
+	reader, err := opener()
+	if err != nil {
+		return v1.Hash{}, 0, err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, reader)
+	if err != nil {
+		return v1.Hash{}, 0, err
+	}
+
+	return v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(hash.Sum(nil))}, size, nil
+}
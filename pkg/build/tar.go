@@ -2,15 +2,27 @@ package build
 
 import (
 	"archive/tar"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"time"
 )
 
-func StreamLayerOpener(imagePath string) func() (io.ReadCloser, error) {
-	modTime := time.Now()
+// The layout of the containerdisk layer, which is owned by the qemu user of KubeVirt.
+const (
+	DiskDir       = "disk/"
+	DiskImagePath = "disk/disk.img"
+	DiskDirMode   = 0555
+	DiskImageMode = 0444
+	QemuID        = 107
+	QemuName      = "qemu"
+)
 
+// StreamLayerOpener returns an opener for a layer with the disk image at imagePath. All
+// entries of the layer get modTime, so that the layer of a disk image is reproducible.
+func StreamLayerOpener(imagePath string, modTime time.Time) func() (io.ReadCloser, error) {
 	return func() (io.ReadCloser, error) {
 		fileErrorChan := make(chan error)
 		pipeReader, pipeWriter := io.Pipe()
@@ -56,35 +68,14 @@ func StreamLayerOpener(imagePath string) func() (io.ReadCloser, error) {
 }
 
 func addFileToTarWriter(file io.Reader, stat os.FileInfo, modTime time.Time, tarWriter *tar.Writer) error {
-	header := &tar.Header{
-		Typeflag: tar.TypeDir,
-		Name:     "disk/",
-		Mode:     0555,
-		Uid:      107,
-		Gid:      107,
-		Uname:    "qemu",
-		Gname:    "qemu",
-		ModTime:  modTime,
-	}
+	dirHeader, imageHeader := layerHeaders(stat.Size(), modTime)
 
-	err := tarWriter.WriteHeader(header)
+	err := tarWriter.WriteHeader(dirHeader)
 	if err != nil {
 		return fmt.Errorf("error writing disks directory tar header: %w", err)
 	}
 
-	header = &tar.Header{
-		Typeflag: tar.TypeReg,
-		Uid:      107,
-		Gid:      107,
-		Uname:    "qemu",
-		Gname:    "qemu",
-		Name:     "disk/disk.img",
-		Size:     stat.Size(),
-		Mode:     0444,
-		ModTime:  stat.ModTime(),
-	}
-
-	err = tarWriter.WriteHeader(header)
+	err = tarWriter.WriteHeader(imageHeader)
 	if err != nil {
 		return fmt.Errorf("error writing image file tar header: %w", err)
 	}
@@ -96,3 +87,104 @@ func addFileToTarWriter(file io.Reader, stat os.FileInfo, modTime time.Time, tar
 
 	return nil
 }
+
+func layerHeaders(size int64, modTime time.Time) (dirHeader, imageHeader *tar.Header) {
+	dirHeader = &tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     DiskDir,
+		Mode:     DiskDirMode,
+		Uid:      QemuID,
+		Gid:      QemuID,
+		Uname:    QemuName,
+		Gname:    QemuName,
+		ModTime:  modTime,
+	}
+	imageHeader = &tar.Header{
+		Typeflag: tar.TypeReg,
+		Uid:      QemuID,
+		Gid:      QemuID,
+		Uname:    QemuName,
+		Gname:    QemuName,
+		Name:     DiskImagePath,
+		Size:     size,
+		Mode:     DiskImageMode,
+		ModTime:  modTime,
+	}
+
+	return dirHeader, imageHeader
+}
+
+// layerSectionReader returns the same tar stream as StreamLayerOpener with random access,
+// without copying the disk image. The returned file must be closed after reading.
+func layerSectionReader(imagePath string, modTime time.Time) (*io.SectionReader, *os.File, error) {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error getting file information with stat: %w", err)
+	}
+
+	var headers bytes.Buffer
+	tarWriter := tar.NewWriter(&headers)
+	dirHeader, imageHeader := layerHeaders(stat.Size(), modTime)
+	if err = tarWriter.WriteHeader(dirHeader); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error writing disks directory tar header: %w", err)
+	}
+	if err = tarWriter.WriteHeader(imageHeader); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error writing image file tar header: %w", err)
+	}
+
+	// The file is padded to full blocks and the archive ends with two zero blocks
+	const blockSize = 512
+	padding := (blockSize - stat.Size()%blockSize) % blockSize
+	trailer := make([]byte, padding+2*blockSize)
+
+	reader := concatReaderAt{
+		io.NewSectionReader(bytes.NewReader(headers.Bytes()), 0, int64(headers.Len())),
+		io.NewSectionReader(file, 0, stat.Size()),
+		io.NewSectionReader(bytes.NewReader(trailer), 0, int64(len(trailer))),
+	}
+
+	return io.NewSectionReader(reader, 0, reader.size()), file, nil
+}
+
+// concatReaderAt reads from its parts as if they were concatenated.
+type concatReaderAt []*io.SectionReader
+
+func (c concatReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for _, part := range c {
+		if n == len(p) {
+			break
+		}
+		if off >= part.Size() {
+			off -= part.Size()
+			continue
+		}
+
+		m, err := part.ReadAt(p[n:], off)
+		n += m
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		off = 0
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (c concatReaderAt) size() (size int64) {
+	for _, part := range c {
+		size += part.Size()
+	}
+	return size
+}
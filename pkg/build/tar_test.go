@@ -2,10 +2,12 @@ package build
 
 import (
 	"archive/tar"
+	"bytes"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -22,7 +24,8 @@ var _ = Describe("Tar", func() {
 		imageStat, err := os.Stat(imageName)
 		Expect(err).ToNot(HaveOccurred())
 
-		reader, err := StreamLayerOpener(imageName)()
+		modTime := time.Unix(1700000000, 0)
+		reader, err := StreamLayerOpener(imageName, modTime)()
 		Expect(err).ToNot(HaveOccurred())
 
 		tarReader := tar.NewReader(reader)
@@ -33,6 +36,7 @@ var _ = Describe("Tar", func() {
 		Expect(int32(dir.Typeflag)).To(Equal(tar.TypeDir))
 		Expect(dir.Uid).To(Equal(107))
 		Expect(dir.Gid).To(Equal(107))
+		Expect(dir.ModTime).To(BeTemporally("==", modTime))
 
 		image, err := tarReader.Next()
 		Expect(err).ToNot(HaveOccurred())
@@ -41,10 +45,36 @@ var _ = Describe("Tar", func() {
 		Expect(image.Size).To(Equal(imageStat.Size()))
 		Expect(image.Uid).To(Equal(107))
 		Expect(image.Gid).To(Equal(107))
+		Expect(image.ModTime).To(BeTemporally("==", modTime))
 		data, err := io.ReadAll(tarReader)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(string(data)).To(Equal(imageContent))
 	})
+
+	It("layerSectionReader should read the same tar stream as StreamLayerOpener", func() {
+		imageName := filepath.Join(GinkgoT().TempDir(), "image")
+		Expect(os.WriteFile(imageName, bytes.Repeat([]byte("disk"), 1000), 0600)).To(Succeed())
+		modTime := time.Unix(1700000000, 0)
+
+		reader, err := StreamLayerOpener(imageName, modTime)()
+		Expect(err).ToNot(HaveOccurred())
+		expected, err := io.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+
+		sectionReader, file, err := layerSectionReader(imageName, modTime)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+		Expect(sectionReader.Size()).To(Equal(int64(len(expected))))
+		data, err := io.ReadAll(sectionReader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(expected))
+
+		// Reads across the parts of the stream
+		part := make([]byte, 1024)
+		_, err = sectionReader.ReadAt(part, 1000)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(part).To(Equal(expected[1000:2024]))
+	})
 })
 
 func TestTar(t *testing.T) {
@@ -0,0 +1,212 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"kubevirt.io/containerdisks/pkg/http"
+	"kubevirt.io/containerdisks/pkg/version"
+)
+
+// Link is a file or directory linked from a directory index page.
+type Link struct {
+	// Name is the last path element of the link, without a trailing slash.
+	Name string
+	// URL is the absolute URL of the link.
+	URL string
+	// IsDir is true if the link points to a directory.
+	IsDir bool
+	// ModTime is the modification time shown on the index page, zero if unknown.
+	ModTime time.Time
+	// Size is the size in bytes shown on the index page, -1 if unknown.
+	// Sizes in a human readable format like 1.2K are only approximate.
+	Size int64
+	// Matches contains the submatches of the regular expression the link was found with.
+	Matches []string
+}
+
+// Key returns the value links are sorted by. This is the first submatch of the
+// regular expression the link was found with or its name if there is none.
+func (e *Link) Key() string {
+	if len(e.Matches) > 1 {
+		return e.Matches[1]
+	}
+
+	return e.Name
+}
+
+var (
+	linkRex = regexp.MustCompile(`(?i)<a\s[^>]*href="(?P<href>[^"]+)"[^>]*>`)
+	tagRex  = regexp.MustCompile(`<[^>]*>`)
+	sizeRex = regexp.MustCompile(`^(?P<number>[0-9]+(?:\.[0-9]+)?)(?P<unit>[KMGT])?$`)
+)
+
+// timeFormats are the modification time formats of Apache, nginx and mirror.openshift.com index pages.
+var timeFormats = []struct {
+	rex    *regexp.Regexp
+	layout string
+}{
+	{regexp.MustCompile(`[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}(:[0-9]{2})?`), "2006-01-02 15:04"},
+	{regexp.MustCompile(`[0-9]{2}-[A-Za-z]{3}-[0-9]{4} [0-9]{2}:[0-9]{2}(:[0-9]{2})?`), "02-Jan-2006 15:04"},
+	{regexp.MustCompile(`[0-9]{4}-[A-Za-z]{3}-[0-9]{2} [0-9]{2}:[0-9]{2}(:[0-9]{2})?`), "2006-Jan-02 15:04"},
+}
+
+// List fetches the index page at indexURL and returns all links on it.
+// Links which don't point to a direct child of indexURL, like sort links or the
+// parent directory, are skipped.
+func List(ctx context.Context, getter http.Getter, indexURL string) ([]Link, error) {
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the index URL %q: %v", indexURL, err)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+
+	raw, err := getter.GetAllWithContext(ctx, base.String())
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the index page: %v", err)
+	}
+
+	return parse(base, string(raw)), nil
+}
+
+// Find lists indexURL and returns all links whose name matches rex, sorted in
+// descending order by their keys with compare.
+func Find(ctx context.Context, getter http.Getter, indexURL string, rex *regexp.Regexp, compare version.Comparator) ([]Link, error) {
+	links, err := List(ctx, getter, indexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []Link{}
+	for i := range links {
+		if matches := rex.FindStringSubmatch(links[i].Name); matches != nil {
+			links[i].Matches = matches
+			matching = append(matching, links[i])
+		}
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool {
+		return compare(matching[i].Key(), matching[j].Key()) > 0
+	})
+
+	return matching, nil
+}
+
+// FindNewest returns the link of indexURL whose name matches rex and which has the
+// greatest key according to compare.
+func FindNewest(ctx context.Context, getter http.Getter, indexURL string, rex *regexp.Regexp, compare version.Comparator) (*Link, error) {
+	links, err := Find(ctx, getter, indexURL, rex, compare)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) == 0 {
+		return nil, fmt.Errorf("no link matching %q found in %s", rex.String(), indexURL)
+	}
+
+	return &links[0], nil
+}
+
+func parse(base *url.URL, content string) []Link {
+	links := []Link{}
+	seen := map[string]bool{}
+
+	locations := linkRex.FindAllStringSubmatchIndex(content, -1)
+	for i, location := range locations {
+		href := content[location[2]:location[3]]
+		ref, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+
+		resolved := base.ResolveReference(ref)
+		resolved.RawQuery = ""
+		resolved.Fragment = ""
+		isDir := strings.HasSuffix(resolved.Path, "/")
+		name := path.Base(strings.TrimSuffix(resolved.Path, "/"))
+		parent := strings.TrimSuffix(path.Dir(strings.TrimSuffix(resolved.Path, "/")), "/") + "/"
+		if resolved.Host != base.Host || parent != base.Path || resolved.Path == base.Path || seen[resolved.String()] {
+			continue
+		}
+		seen[resolved.String()] = true
+
+		// The details of a link are between the end of the link and the next link
+		end := len(content)
+		if i+1 < len(locations) {
+			end = locations[i+1][0]
+		}
+		modTime, size := parseDetails(content[location[1]:end])
+
+		links = append(links, Link{
+			Name:    name,
+			URL:     resolved.String(),
+			IsDir:   isDir,
+			ModTime: modTime,
+			Size:    size,
+		})
+	}
+
+	return links
+}
+
+func parseDetails(details string) (modTime time.Time, size int64) {
+	// Only look at the text after the link label
+	if idx := strings.Index(strings.ToLower(details), "</a>"); idx >= 0 {
+		details = details[idx+len("</a>"):]
+	}
+	details = tagRex.ReplaceAllString(details, " ")
+
+	for _, format := range timeFormats {
+		match := format.rex.FindString(details)
+		if match == "" {
+			continue
+		}
+
+		layout := format.layout
+		if len(match) > len(layout) {
+			layout += ":05"
+		}
+		if t, err := time.Parse(layout, match); err == nil {
+			modTime = t
+			details = strings.Replace(details, match, " ", 1)
+			break
+		}
+	}
+
+	return modTime, parseSize(strings.Fields(details))
+}
+
+func parseSize(fields []string) int64 {
+	units := map[string]float64{
+		"":  1,
+		"K": 1 << 10,
+		"M": 1 << 20,
+		"G": 1 << 30,
+		"T": 1 << 40,
+	}
+
+	for _, field := range fields {
+		matches := sizeRex.FindStringSubmatch(field)
+		if matches == nil {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			continue
+		}
+
+		return int64(number * units[matches[2]])
+	}
+
+	return -1
+}
@@ -0,0 +1,121 @@
+package discovery
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/version"
+	"kubevirt.io/containerdisks/testutil"
+)
+
+func mustParseTime(layout, value string) time.Time {
+	t, err := time.Parse(layout, value)
+	Expect(err).NotTo(HaveOccurred())
+	return t
+}
+
+var _ = Describe("Discovery", func() {
+	It("List should parse Apache table index pages", func() {
+		entries, err := List(context.Background(), testutil.NewMockGetter("testdata/apache.html"), "https://cloud.centos.org/centos")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(7))
+		Expect(entries[2]).To(Equal(Link{
+			Name:    "8-stream",
+			URL:     "https://cloud.centos.org/centos/8-stream/",
+			IsDir:   true,
+			ModTime: mustParseTime("2006-01-02 15:04", "2021-06-04 10:43"),
+			Size:    -1,
+		}))
+		Expect(entries[6]).To(Equal(Link{
+			Name:    "HEADER.html",
+			URL:     "https://cloud.centos.org/centos/HEADER.html",
+			IsDir:   false,
+			ModTime: mustParseTime("2006-01-02 15:04", "2020-12-02 14:52"),
+			Size:    1228,
+		}))
+	})
+
+	It("List should parse Apache pre formatted index pages", func() {
+		entries, err := List(
+			context.Background(), testutil.NewMockGetter("testdata/apache-pre.html"),
+			"https://dl.fedoraproject.org/pub/alt/releases/35/Cloud/x86_64/images/",
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(4))
+		Expect(entries[1].Name).To(Equal("Fedora-Cloud-Base-35-1.2.x86_64.qcow2"))
+		Expect(entries[1].ModTime).To(Equal(mustParseTime("2006-01-02 15:04", "2021-10-26 16:56")))
+		Expect(entries[1].Size).To(Equal(int64(358 * 1024 * 1024)))
+	})
+
+	It("List should parse nginx index pages", func() {
+		entries, err := List(context.Background(), testutil.NewMockGetter("testdata/nginx.html"), "https://example.org/releases/")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(4))
+		Expect(entries[3]).To(Equal(Link{
+			Name:    "CHECKSUM",
+			URL:     "https://example.org/releases/CHECKSUM",
+			ModTime: mustParseTime("02-Jan-2006 15:04", "10-Oct-2023 08:45"),
+			Size:    2048,
+		}))
+	})
+
+	It("List should parse mirror.openshift.com index pages", func() {
+		entries, err := List(
+			context.Background(), testutil.NewMockGetter("testdata/openshift.html"),
+			"https://mirror.openshift.com/pub/openshift-v4/dependencies/rhcos/",
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(10))
+		Expect(entries[1].Name).To(Equal("4.10"))
+		Expect(entries[1].IsDir).To(BeTrue())
+		Expect(entries[1].ModTime).To(Equal(mustParseTime("2006-Jan-02 15:04", "2023-Apr-19 12:00")))
+	})
+
+	DescribeTable("Find should filter and sort entries",
+		func(mockFile, rex string, compare version.Comparator, names []string) {
+			entries, err := Find(context.Background(), testutil.NewMockGetter(mockFile), "https://example.org/", regexp.MustCompile(rex), compare)
+			Expect(err).NotTo(HaveOccurred())
+
+			got := []string{}
+			for _, entry := range entries {
+				got = append(got, entry.Name)
+			}
+			Expect(got).To(Equal(names))
+		},
+		Entry("numeric versions", "testdata/openshift.html", `^4\.[0-9]+$`, version.Comparator(version.CompareRPM),
+			[]string{"4.13", "4.12", "4.11", "4.10", "4.9", "4.8", "4.2", "4.1"}),
+		Entry("lexical versions", "testdata/openshift.html", `^4\.[0-9]+$`, version.Comparator(strings.Compare),
+			[]string{"4.9", "4.8", "4.2", "4.13", "4.12", "4.11", "4.10", "4.1"}),
+		Entry("submatches as keys", "testdata/apache.html", `^([0-9]+)-stream$`, version.Comparator(version.CompareRPM),
+			[]string{"10-stream", "9-stream", "8-stream"}),
+		Entry("build dates", "testdata/nginx.html", `^9-[0-9.]+$`, version.Comparator(version.CompareRPM),
+			[]string{"9-20231010.0", "9-20231002.10", "9-20230925.0"}),
+	)
+
+	It("FindNewest should return the newest entry", func() {
+		entry, err := FindNewest(
+			context.Background(), testutil.NewMockGetter("testdata/openshift.html"), "https://example.org/", regexp.MustCompile(`^4\.[0-9]+$`), version.CompareRPM,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Name).To(Equal("4.13"))
+		Expect(entry.URL).To(Equal("https://example.org/4.13/"))
+	})
+
+	It("FindNewest should fail if nothing matches", func() {
+		_, err := FindNewest(
+			context.Background(), testutil.NewMockGetter("testdata/openshift.html"), "https://example.org/", regexp.MustCompile(`^5\.[0-9]+$`), version.CompareRPM,
+		)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func TestDiscovery(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Discovery Suite")
+}
@@ -0,0 +1,95 @@
+package disk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	FormatQcow2 = "qcow2"
+	FormatVMDK  = "vmdk"
+	FormatRaw   = "raw"
+
+	sectorSize = 512
+)
+
+// ErrUnsafe is wrapped by all errors about images which must not be published,
+// because they reference files outside of the image, are encrypted or are corrupt.
+var ErrUnsafe = errors.New("unsafe disk image")
+
+// Info describes an inspected disk image.
+type Info struct {
+	// Format is one of FormatQcow2, FormatVMDK and FormatRaw.
+	Format string
+	// VirtualSize is the size of the disk in bytes as seen by the guest.
+	VirtualSize uint64
+}
+
+var (
+	qcow2Magic       = []byte{'Q', 'F', 'I', 0xfb}
+	vmdkSparseMagic  = []byte("KDMV")
+	vmdkDescriptor   = []byte("# Disk DescriptorFile")
+	luksMagic        = []byte{'L', 'U', 'K', 'S', 0xba, 0xbe}
+	headerProbeBytes = 64
+)
+
+// Inspect parses the headers of the disk image at path. Images with backing files,
+// external data files or encryption as well as images with corrupt headers are
+// rejected with an error wrapping ErrUnsafe. Images in an unknown format are
+// treated as raw images.
+func Inspect(path string) (*Info, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return inspect(file, stat.Size())
+}
+
+func inspect(r io.ReaderAt, size int64) (*Info, error) {
+	probe := make([]byte, headerProbeBytes)
+	n, err := r.ReadAt(probe, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	probe = probe[:n]
+
+	switch {
+	case bytes.HasPrefix(probe, qcow2Magic):
+		return inspectQcow2(r, size)
+	case bytes.HasPrefix(probe, vmdkSparseMagic):
+		return inspectVMDK(r, size)
+	case bytes.HasPrefix(probe, vmdkDescriptor):
+		return nil, unsafef("vmdk descriptor files reference external extents")
+	case bytes.HasPrefix(probe, luksMagic):
+		return nil, unsafef("the image is LUKS encrypted")
+	default:
+		return inspectRaw(r, size)
+	}
+}
+
+func unsafef(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsafe, fmt.Sprintf(format, args...))
+}
+
+// readAt reads exactly len(buf) bytes at off and reports a truncated image as unsafe.
+func readAt(r io.ReaderAt, buf []byte, off int64) error {
+	n, err := r.ReadAt(buf, off)
+	if n == len(buf) {
+		return nil
+	}
+	if err == nil || err == io.EOF {
+		return unsafef("the image is truncated at offset %d", off+int64(n))
+	}
+
+	return err
+}
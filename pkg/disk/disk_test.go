@@ -0,0 +1,247 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const clusterBits = 16
+
+func encode(order binary.ByteOrder, data interface{}) []byte {
+	var buf bytes.Buffer
+	Expect(binary.Write(&buf, order, data)).To(Succeed())
+	return buf.Bytes()
+}
+
+func qcow2Image(modify func(header *qcow2Header, v3 *qcow2V3Header, extensions *[]byte)) []byte {
+	header := qcow2Header{
+		Magic:                 binary.BigEndian.Uint32(qcow2Magic),
+		Version:               3,
+		ClusterBits:           clusterBits,
+		Size:                  10 << 30,
+		L1Size:                160,
+		L1TableOffset:         3 << clusterBits,
+		RefcountTableOffset:   1 << clusterBits,
+		RefcountTableClusters: 1,
+	}
+	v3 := qcow2V3Header{
+		RefcountOrder: 4,
+		HeaderLength:  qcow2V3HeaderLength,
+	}
+	var extensions []byte
+	if modify != nil {
+		modify(&header, &v3, &extensions)
+	}
+
+	image := encode(binary.BigEndian, header)
+	if header.Version == 3 {
+		image = append(image, encode(binary.BigEndian, v3)...)
+	}
+	image = append(image, extensions...)
+	image = append(image, make([]byte, 4<<clusterBits-len(image))...)
+
+	return image
+}
+
+func qcow2Extension(extType uint32, data string) []byte {
+	ext := make([]byte, 8+(len(data)+7)/8*8)
+	binary.BigEndian.PutUint32(ext[0:4], extType)
+	binary.BigEndian.PutUint32(ext[4:8], uint32(len(data)))
+	copy(ext[8:], data)
+	return ext
+}
+
+const monolithicSparseDescriptor = `# Disk DescriptorFile
+version=1
+CID=12345678
+parentCID=ffffffff
+createType="monolithicSparse"
+
+# Extent description
+RW 20971520 SPARSE "disk.vmdk"
+`
+
+func vmdkImage(descriptor string, modify func(header *vmdkSparseHeader)) []byte {
+	header := vmdkSparseHeader{
+		Magic:              binary.LittleEndian.Uint32(vmdkSparseMagic),
+		Version:            1,
+		Flags:              3,
+		Capacity:           20971520,
+		GrainSize:          128,
+		DescriptorOffset:   1,
+		DescriptorSize:     20,
+		NumGTEsPerGT:       512,
+		SingleEndLineChar:  '\n',
+		NonEndLineChar:     ' ',
+		DoubleEndLineChar1: '\r',
+		DoubleEndLineChar2: '\n',
+	}
+	if modify != nil {
+		modify(&header)
+	}
+
+	image := make([]byte, 64*sectorSize)
+	copy(image, encode(binary.LittleEndian, header))
+	copy(image[sectorSize:], descriptor)
+
+	return image
+}
+
+func mbrImage(sectors uint32) []byte {
+	image := make([]byte, 2048*sectorSize)
+	entry := image[mbrPartitionTableOffset:]
+	entry[0] = 0x80
+	entry[4] = 0x83
+	binary.LittleEndian.PutUint32(entry[8:12], 1)
+	binary.LittleEndian.PutUint32(entry[12:16], sectors)
+	copy(image[sectorSize-2:], mbrSignature)
+	return image
+}
+
+func gptImage(modify func(header *gptHeader, entries []byte)) []byte {
+	const sectors = 2048
+	image := make([]byte, sectors*sectorSize)
+
+	protective := image[mbrPartitionTableOffset:]
+	protective[4] = mbrProtectiveType
+	binary.LittleEndian.PutUint32(protective[8:12], 1)
+	binary.LittleEndian.PutUint32(protective[12:16], sectors-1)
+	copy(image[sectorSize-2:], mbrSignature)
+
+	entries := image[2*sectorSize : 2*sectorSize+128*gptPartitionEntrySize]
+	copy(entries[0:16], "partition-type-1")
+	binary.LittleEndian.PutUint64(entries[32:40], 64)
+	binary.LittleEndian.PutUint64(entries[40:48], 1000)
+
+	header := gptHeader{
+		Revision:           0x00010000,
+		HeaderSize:         gptHeaderMinSize,
+		CurrentLBA:         1,
+		BackupLBA:          sectors - 1,
+		FirstUsableLBA:     34,
+		LastUsableLBA:      sectors - 34,
+		PartitionEntryLBA:  2,
+		NumPartitions:      128,
+		PartitionEntrySize: gptPartitionEntrySize,
+	}
+	copy(header.Signature[:], gptSignature)
+	header.PartitionArrayCRC = crc32.ChecksumIEEE(entries)
+	if modify != nil {
+		modify(&header, entries)
+	}
+
+	if header.HeaderCRC32 == 0 {
+		header.HeaderCRC32 = crc32.ChecksumIEEE(encode(binary.LittleEndian, header)[:header.HeaderSize])
+	}
+	copy(image[sectorSize:], encode(binary.LittleEndian, header))
+
+	return image
+}
+
+var _ = Describe("Inspect", func() {
+	var tmpDir string
+
+	BeforeEach(func() {
+		tmpDir = GinkgoT().TempDir()
+	})
+
+	writeImage := func(data []byte) string {
+		path := filepath.Join(tmpDir, "disk.img")
+		Expect(os.WriteFile(path, data, 0600)).To(Succeed())
+		return path
+	}
+
+	DescribeTable("should accept safe images", func(data []byte, expected *Info) {
+		info, err := Inspect(writeImage(data))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info).To(Equal(expected))
+	},
+		Entry("qcow2 version 3", qcow2Image(nil), &Info{Format: FormatQcow2, VirtualSize: 10 << 30}),
+		Entry("qcow2 version 2", qcow2Image(func(header *qcow2Header, _ *qcow2V3Header, _ *[]byte) {
+			header.Version = 2
+		}), &Info{Format: FormatQcow2, VirtualSize: 10 << 30}),
+		Entry("qcow2 with a dirty flag and a feature name table", qcow2Image(func(_ *qcow2Header, v3 *qcow2V3Header, extensions *[]byte) {
+			v3.IncompatibleFeatures = qcow2IncompatDirty
+			*extensions = qcow2Extension(0x6803f857, "feature names")
+		}), &Info{Format: FormatQcow2, VirtualSize: 10 << 30}),
+		Entry("monolithic sparse vmdk", vmdkImage(monolithicSparseDescriptor, nil),
+			&Info{Format: FormatVMDK, VirtualSize: 20971520 * sectorSize}),
+		Entry("raw without partition table", make([]byte, 4096), &Info{Format: FormatRaw, VirtualSize: 4096}),
+		Entry("raw with MBR", mbrImage(1024), &Info{Format: FormatRaw, VirtualSize: 2048 * sectorSize}),
+		Entry("raw with GPT", gptImage(nil), &Info{Format: FormatRaw, VirtualSize: 2048 * sectorSize}),
+	)
+
+	DescribeTable("should reject unsafe images", func(data []byte, message string) {
+		_, err := Inspect(writeImage(data))
+		Expect(err).To(HaveOccurred())
+		Expect(errors.Is(err, ErrUnsafe)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(message))
+	},
+		Entry("qcow2 with a backing file", qcow2Image(func(header *qcow2Header, _ *qcow2V3Header, _ *[]byte) {
+			header.BackingFileOffset = 512
+			header.BackingFileSize = 10
+		}), "references a backing file"),
+		Entry("qcow2 with AES encryption", qcow2Image(func(header *qcow2Header, _ *qcow2V3Header, _ *[]byte) {
+			header.CryptMethod = 1
+		}), "is encrypted"),
+		Entry("qcow2 with a LUKS header extension", qcow2Image(func(_ *qcow2Header, _ *qcow2V3Header, extensions *[]byte) {
+			*extensions = qcow2Extension(qcow2ExtEncryption, "0123456789abcdef")
+		}), "is encrypted"),
+		Entry("qcow2 with an external data file", qcow2Image(func(_ *qcow2Header, v3 *qcow2V3Header, _ *[]byte) {
+			v3.IncompatibleFeatures = qcow2IncompatDataFile
+		}), "external data file"),
+		Entry("qcow2 with an external data file name", qcow2Image(func(_ *qcow2Header, _ *qcow2V3Header, extensions *[]byte) {
+			*extensions = qcow2Extension(qcow2ExtDataFile, "/etc/shadow")
+		}), "external data file"),
+		Entry("qcow2 marked as corrupt", qcow2Image(func(_ *qcow2Header, v3 *qcow2V3Header, _ *[]byte) {
+			v3.IncompatibleFeatures = qcow2IncompatCorrupt
+		}), "marked as corrupt"),
+		Entry("qcow2 with unknown incompatible features", qcow2Image(func(_ *qcow2Header, v3 *qcow2V3Header, _ *[]byte) {
+			v3.IncompatibleFeatures = 1 << 20
+		}), "unknown incompatible features"),
+		Entry("qcow2 version 1", qcow2Image(func(header *qcow2Header, _ *qcow2V3Header, _ *[]byte) {
+			header.Version = 1
+		}), "unsupported qcow2 version"),
+		Entry("qcow2 with invalid cluster bits", qcow2Image(func(header *qcow2Header, _ *qcow2V3Header, _ *[]byte) {
+			header.ClusterBits = 40
+		}), "invalid qcow2 cluster bits"),
+		Entry("qcow2 with an L1 table outside of the image", qcow2Image(func(header *qcow2Header, _ *qcow2V3Header, _ *[]byte) {
+			header.L1TableOffset = 100 << clusterBits
+		}), "L1 table lies outside"),
+		Entry("truncated qcow2", qcow2Image(nil)[:80], "truncated"),
+		Entry("vmdk with a parent", vmdkImage(monolithicSparseDescriptor+`parentFileNameHint="/var/lib/base.vmdk"`+"\n", nil),
+			"references a parent image"),
+		Entry("vmdk with a flat extent", vmdkImage(`createType="monolithicSparse"`+"\n"+`RW 20971520 FLAT "/dev/sda" 0`+"\n", nil),
+			"external flat extent"),
+		Entry("vmdk split into several files", vmdkImage(`createType="twoGbMaxExtentSparse"`+"\n", nil),
+			"unsupported vmdk create type"),
+		Entry("vmdk with corrupt line endings", vmdkImage(monolithicSparseDescriptor, func(header *vmdkSparseHeader) {
+			header.DoubleEndLineChar1 = '\n'
+		}), "vmdk header is corrupt"),
+		Entry("vmdk descriptor file", []byte("# Disk DescriptorFile\nRW 100 FLAT \"/dev/sda\" 0\n"), "external extents"),
+		Entry("LUKS encrypted raw image", append([]byte{'L', 'U', 'K', 'S', 0xba, 0xbe, 0, 2}, make([]byte, 4096)...), "LUKS encrypted"),
+		Entry("MBR with a partition outside of the image", mbrImage(4096), "MBR partition 1 lies outside"),
+		Entry("GPT with a corrupt header checksum", gptImage(func(header *gptHeader, _ []byte) {
+			header.HeaderCRC32 = 1
+		}), "GPT header checksum"),
+		Entry("GPT with a corrupt partition array", gptImage(func(_ *gptHeader, entries []byte) {
+			entries[200] = 1
+		}), "GPT partition array checksum"),
+		Entry("GPT describing a larger disk", gptImage(func(header *gptHeader, _ []byte) {
+			header.LastUsableLBA = 1 << 30
+		}), "larger than the image"),
+	)
+})
+
+func TestDisk(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Disk Suite")
+}
@@ -0,0 +1,168 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+const (
+	qcow2V2HeaderLength = 72
+	qcow2V3HeaderLength = 104
+
+	qcow2MinClusterBits = 9
+	qcow2MaxClusterBits = 21
+
+	qcow2IncompatDirty         = 1 << 0
+	qcow2IncompatCorrupt       = 1 << 1
+	qcow2IncompatDataFile      = 1 << 2
+	qcow2IncompatCompression   = 1 << 3
+	qcow2IncompatExtendedL2    = 1 << 4
+	qcow2IncompatKnownFeatures = qcow2IncompatDirty | qcow2IncompatCorrupt | qcow2IncompatDataFile |
+		qcow2IncompatCompression | qcow2IncompatExtendedL2
+
+	qcow2ExtEnd        = 0x00000000
+	qcow2ExtEncryption = 0x0537be77
+	qcow2ExtDataFile   = 0x44415441
+)
+
+type qcow2Header struct {
+	Magic                 uint32
+	Version               uint32
+	BackingFileOffset     uint64
+	BackingFileSize       uint32
+	ClusterBits           uint32
+	Size                  uint64
+	CryptMethod           uint32
+	L1Size                uint32
+	L1TableOffset         uint64
+	RefcountTableOffset   uint64
+	RefcountTableClusters uint32
+	NbSnapshots           uint32
+	SnapshotsOffset       uint64
+}
+
+type qcow2V3Header struct {
+	IncompatibleFeatures uint64
+	CompatibleFeatures   uint64
+	AutoclearFeatures    uint64
+	RefcountOrder        uint32
+	HeaderLength         uint32
+}
+
+func inspectQcow2(r io.ReaderAt, size int64) (*Info, error) {
+	buf := make([]byte, qcow2V3HeaderLength)
+	if err := readAt(r, buf[:qcow2V2HeaderLength], 0); err != nil {
+		return nil, err
+	}
+
+	var header qcow2Header
+	if err := binary.Read(bytes.NewReader(buf[:qcow2V2HeaderLength]), binary.BigEndian, &header); err != nil {
+		return nil, err
+	}
+
+	headerLength := uint64(qcow2V2HeaderLength)
+	switch header.Version {
+	case 2:
+	case 3:
+		if err := readAt(r, buf[qcow2V2HeaderLength:], qcow2V2HeaderLength); err != nil {
+			return nil, err
+		}
+		var v3 qcow2V3Header
+		if err := binary.Read(bytes.NewReader(buf[qcow2V2HeaderLength:]), binary.BigEndian, &v3); err != nil {
+			return nil, err
+		}
+		if err := checkQcow2Features(v3); err != nil {
+			return nil, err
+		}
+		headerLength = uint64(v3.HeaderLength)
+	default:
+		return nil, unsafef("unsupported qcow2 version %d", header.Version)
+	}
+
+	if err := checkQcow2Header(header, uint64(size)); err != nil {
+		return nil, err
+	}
+
+	if err := checkQcow2Extensions(r, headerLength, uint64(1)<<header.ClusterBits); err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		Format:      FormatQcow2,
+		VirtualSize: header.Size,
+	}, nil
+}
+
+func checkQcow2Features(v3 qcow2V3Header) error {
+	if v3.HeaderLength < qcow2V3HeaderLength || v3.HeaderLength%8 != 0 {
+		return unsafef("invalid qcow2 header length %d", v3.HeaderLength)
+	}
+	if v3.IncompatibleFeatures&qcow2IncompatCorrupt != 0 {
+		return unsafef("the qcow2 image is marked as corrupt")
+	}
+	if v3.IncompatibleFeatures&qcow2IncompatDataFile != 0 {
+		return unsafef("the qcow2 image uses an external data file")
+	}
+	if unknown := v3.IncompatibleFeatures &^ qcow2IncompatKnownFeatures; unknown != 0 {
+		return unsafef("the qcow2 image uses unknown incompatible features %#x", unknown)
+	}
+
+	return nil
+}
+
+func checkQcow2Header(header qcow2Header, size uint64) error {
+	if header.BackingFileOffset != 0 || header.BackingFileSize != 0 {
+		return unsafef("the qcow2 image references a backing file")
+	}
+	if header.CryptMethod != 0 {
+		return unsafef("the qcow2 image is encrypted")
+	}
+	if header.ClusterBits < qcow2MinClusterBits || header.ClusterBits > qcow2MaxClusterBits {
+		return unsafef("invalid qcow2 cluster bits %d", header.ClusterBits)
+	}
+
+	clusterSize := uint64(1) << header.ClusterBits
+	tables := []struct {
+		name   string
+		offset uint64
+		length uint64
+	}{
+		{"L1 table", header.L1TableOffset, uint64(header.L1Size) * 8},
+		{"refcount table", header.RefcountTableOffset, uint64(header.RefcountTableClusters) * clusterSize},
+	}
+	for _, table := range tables {
+		if table.offset%clusterSize != 0 {
+			return unsafef("the qcow2 %s is not cluster aligned", table.name)
+		}
+		if table.offset+table.length < table.offset || table.offset+table.length > size {
+			return unsafef("the qcow2 %s lies outside of the image", table.name)
+		}
+	}
+
+	return nil
+}
+
+func checkQcow2Extensions(r io.ReaderAt, offset, clusterSize uint64) error {
+	buf := make([]byte, 8)
+	for offset+8 <= clusterSize {
+		if err := readAt(r, buf, int64(offset)); err != nil {
+			return err
+		}
+		extType := binary.BigEndian.Uint32(buf[:4])
+		extLength := uint64(binary.BigEndian.Uint32(buf[4:]))
+
+		switch extType {
+		case qcow2ExtEnd:
+			return nil
+		case qcow2ExtEncryption:
+			return unsafef("the qcow2 image is encrypted")
+		case qcow2ExtDataFile:
+			return unsafef("the qcow2 image references an external data file")
+		}
+
+		offset += 8 + (extLength+7)/8*8
+	}
+
+	return unsafef("the qcow2 header extensions exceed the first cluster")
+}
@@ -0,0 +1,146 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	mbrPartitionTableOffset = 446
+	mbrPartitionEntrySize   = 16
+	mbrPartitionEntries     = 4
+	mbrProtectiveType       = 0xee
+
+	gptHeaderMinSize      = 92
+	gptPartitionEntrySize = 128
+	gptMaxPartitionArray  = 1 << 20
+)
+
+var (
+	mbrSignature = []byte{0x55, 0xaa}
+	gptSignature = []byte("EFI PART")
+)
+
+type gptHeader struct {
+	Signature          [8]byte
+	Revision           uint32
+	HeaderSize         uint32
+	HeaderCRC32        uint32
+	Reserved           uint32
+	CurrentLBA         uint64
+	BackupLBA          uint64
+	FirstUsableLBA     uint64
+	LastUsableLBA      uint64
+	DiskGUID           [16]byte
+	PartitionEntryLBA  uint64
+	NumPartitions      uint32
+	PartitionEntrySize uint32
+	PartitionArrayCRC  uint32
+}
+
+func inspectRaw(r io.ReaderAt, size int64) (*Info, error) {
+	if size >= 2*sectorSize {
+		buf := make([]byte, 2*sectorSize)
+		if err := readAt(r, buf, 0); err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(buf[sectorSize-2:sectorSize], mbrSignature) {
+			if err := checkMBR(buf[:sectorSize], uint64(size)); err != nil {
+				return nil, err
+			}
+		}
+		if bytes.HasPrefix(buf[sectorSize:], gptSignature) {
+			if err := checkGPT(r, buf[sectorSize:], uint64(size)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &Info{
+		Format:      FormatRaw,
+		VirtualSize: uint64(size),
+	}, nil
+}
+
+// checkMBR verifies that all partitions of a master boot record lie within the image.
+// Boot sectors of unpartitioned file systems carry the same signature, therefore
+// sectors with invalid status bytes are not treated as partition tables.
+func checkMBR(sector []byte, size uint64) error {
+	entries := sector[mbrPartitionTableOffset : mbrPartitionTableOffset+mbrPartitionEntries*mbrPartitionEntrySize]
+	for i := 0; i < mbrPartitionEntries; i++ {
+		if status := entries[i*mbrPartitionEntrySize]; status != 0x00 && status != 0x80 {
+			return nil
+		}
+	}
+
+	for i := 0; i < mbrPartitionEntries; i++ {
+		entry := entries[i*mbrPartitionEntrySize : (i+1)*mbrPartitionEntrySize]
+		partitionType := entry[4]
+		if partitionType == 0 || partitionType == mbrProtectiveType {
+			continue
+		}
+		start := uint64(binary.LittleEndian.Uint32(entry[8:12]))
+		sectors := uint64(binary.LittleEndian.Uint32(entry[12:16]))
+		if (start+sectors)*sectorSize > size {
+			return unsafef("MBR partition %d lies outside of the image", i+1)
+		}
+	}
+
+	return nil
+}
+
+func checkGPT(r io.ReaderAt, sector []byte, size uint64) error {
+	var header gptHeader
+	if err := binary.Read(bytes.NewReader(sector), binary.LittleEndian, &header); err != nil {
+		return err
+	}
+
+	if header.HeaderSize < gptHeaderMinSize || header.HeaderSize > sectorSize {
+		return unsafef("invalid GPT header size %d", header.HeaderSize)
+	}
+	raw := make([]byte, header.HeaderSize)
+	copy(raw, sector[:header.HeaderSize])
+	binary.LittleEndian.PutUint32(raw[16:20], 0)
+	if crc32.ChecksumIEEE(raw) != header.HeaderCRC32 {
+		return unsafef("the GPT header checksum does not match")
+	}
+
+	sectors := size / sectorSize
+	if header.CurrentLBA != 1 || header.FirstUsableLBA > header.LastUsableLBA || header.LastUsableLBA >= sectors {
+		return unsafef("the GPT header describes a disk larger than the image")
+	}
+	if header.PartitionEntrySize < gptPartitionEntrySize || header.PartitionEntrySize%8 != 0 {
+		return unsafef("invalid GPT partition entry size %d", header.PartitionEntrySize)
+	}
+
+	arraySize := uint64(header.NumPartitions) * uint64(header.PartitionEntrySize)
+	arrayOffset := header.PartitionEntryLBA * sectorSize
+	if arraySize > gptMaxPartitionArray || header.PartitionEntryLBA >= sectors || arrayOffset+arraySize > size {
+		return unsafef("the GPT partition array lies outside of the image")
+	}
+	entries := make([]byte, arraySize)
+	if err := readAt(r, entries, int64(arrayOffset)); err != nil {
+		return err
+	}
+	if crc32.ChecksumIEEE(entries) != header.PartitionArrayCRC {
+		return unsafef("the GPT partition array checksum does not match")
+	}
+
+	unused := make([]byte, 16)
+	for i := uint32(0); i < header.NumPartitions; i++ {
+		entry := entries[i*header.PartitionEntrySize : (i+1)*header.PartitionEntrySize]
+		if bytes.Equal(entry[:16], unused) {
+			continue
+		}
+		first := binary.LittleEndian.Uint64(entry[32:40])
+		last := binary.LittleEndian.Uint64(entry[40:48])
+		if first < header.FirstUsableLBA || first > last || last > header.LastUsableLBA {
+			return unsafef("GPT partition %d lies outside of the usable area", i+1)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package disk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+const (
+	vmdkMaxDescriptorSize = 1 << 20
+	vmdkNoParentCID       = "ffffffff"
+)
+
+type vmdkSparseHeader struct {
+	Magic              uint32
+	Version            uint32
+	Flags              uint32
+	Capacity           uint64
+	GrainSize          uint64
+	DescriptorOffset   uint64
+	DescriptorSize     uint64
+	NumGTEsPerGT       uint32
+	RgdOffset          uint64
+	GdOffset           uint64
+	OverHead           uint64
+	UncleanShutdown    uint8
+	SingleEndLineChar  byte
+	NonEndLineChar     byte
+	DoubleEndLineChar1 byte
+	DoubleEndLineChar2 byte
+	CompressAlgorithm  uint16
+}
+
+// vmdkCreateTypes are the create types of single file images, whose only extent is
+// the sparse extent carrying the embedded descriptor.
+var vmdkCreateTypes = map[string]bool{
+	"monolithicSparse": true,
+	"streamOptimized":  true,
+}
+
+func inspectVMDK(r io.ReaderAt, size int64) (*Info, error) {
+	buf := make([]byte, binary.Size(vmdkSparseHeader{}))
+	if err := readAt(r, buf, 0); err != nil {
+		return nil, err
+	}
+
+	var header vmdkSparseHeader
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &header); err != nil {
+		return nil, err
+	}
+
+	if header.Version < 1 || header.Version > 3 {
+		return nil, unsafef("unsupported vmdk version %d", header.Version)
+	}
+	if header.SingleEndLineChar != '\n' || header.NonEndLineChar != ' ' ||
+		header.DoubleEndLineChar1 != '\r' || header.DoubleEndLineChar2 != '\n' {
+		return nil, unsafef("the vmdk header is corrupt")
+	}
+	if header.GrainSize < 8 || header.GrainSize&(header.GrainSize-1) != 0 {
+		return nil, unsafef("invalid vmdk grain size %d", header.GrainSize)
+	}
+	if header.DescriptorOffset == 0 || header.DescriptorSize == 0 {
+		return nil, unsafef("the vmdk image has no embedded descriptor")
+	}
+	if header.DescriptorSize*sectorSize > vmdkMaxDescriptorSize ||
+		(header.DescriptorOffset+header.DescriptorSize)*sectorSize > uint64(size) {
+		return nil, unsafef("the vmdk descriptor lies outside of the image")
+	}
+
+	descriptor := make([]byte, header.DescriptorSize*sectorSize)
+	if err := readAt(r, descriptor, int64(header.DescriptorOffset*sectorSize)); err != nil {
+		return nil, err
+	}
+	if err := checkVMDKDescriptor(descriptor); err != nil {
+		return nil, err
+	}
+
+	return &Info{
+		Format:      FormatVMDK,
+		VirtualSize: header.Capacity * sectorSize,
+	}, nil
+}
+
+func checkVMDKDescriptor(descriptor []byte) error {
+	if i := bytes.IndexByte(descriptor, 0); i >= 0 {
+		descriptor = descriptor[:i]
+	}
+
+	createType, extents := "", 0
+	scanner := bufio.NewScanner(bytes.NewReader(descriptor))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, "="); ok {
+			key = strings.TrimSpace(key)
+			value = strings.Trim(strings.TrimSpace(value), `"`)
+			switch key {
+			case "parentCID":
+				if strings.ToLower(value) != vmdkNoParentCID {
+					return unsafef("the vmdk image references a parent image")
+				}
+			case "parentFileNameHint":
+				return unsafef("the vmdk image references a parent image")
+			case "createType":
+				createType = value
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && (fields[0] == "RW" || fields[0] == "RDONLY" || fields[0] == "NOACCESS") {
+			if fields[2] != "SPARSE" {
+				return unsafef("the vmdk image references an external %s extent", strings.ToLower(fields[2]))
+			}
+			extents++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return unsafef("the vmdk descriptor is corrupt: %v", err)
+	}
+
+	if !vmdkCreateTypes[createType] {
+		return unsafef("unsupported vmdk create type %q", createType)
+	}
+	if extents != 1 {
+		return unsafef("the vmdk image has %d extents, expected exactly one", extents)
+	}
+
+	return nil
+}
@@ -0,0 +1,10 @@
+#cloud-config
+users:
+  - name: {{ or .Username "admin" }}
+    groups: wheel
+    ssh_authorized_keys:
+      {{- range .AuthorizedKeys}}
+      - {{.}}
+      {{- else }}
+      - ssh-rsa AAAA...
+      {{- end}}
\ No newline at end of file
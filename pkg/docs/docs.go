@@ -13,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 	v1 "kubevirt.io/api/core/v1"
+	cdiv1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 )
 
 type TemplateData struct {
@@ -28,9 +29,15 @@ type UserData struct {
 
 type Option func(vm *v1.VirtualMachine)
 
+// containerDiskName is the name of the disk and volume booting the containerdisk.
+const containerDiskName = "containerdisk"
+
 //go:embed data/cloudinit.tpl
 var cloudinitTemplate string
 
+//go:embed data/cloudinit-freebsd.tpl
+var cloudinitFreeBSDTemplate string
+
 //go:embed data/ignition.tpl
 var ignitionTemplate string
 
@@ -72,7 +79,7 @@ func BasicVM(name, image string) *v1.VirtualMachine {
 						Devices: v1.Devices{
 							Disks: []v1.Disk{
 								{
-									Name: "containerdisk",
+									Name: containerDiskName,
 									DiskDevice: v1.DiskDevice{
 										Disk: &v1.DiskTarget{
 											Bus: "virtio",
@@ -84,7 +91,7 @@ func BasicVM(name, image string) *v1.VirtualMachine {
 					},
 					Volumes: []v1.Volume{
 						{
-							Name: "containerdisk",
+							Name: containerDiskName,
 							VolumeSource: v1.VolumeSource{
 								ContainerDisk: &v1.ContainerDiskSource{
 									Image: image,
@@ -160,6 +167,55 @@ func WithSecureBoot() Option {
 	}
 }
 
+// WithInstancetypeInference imports the containerdisk into a DataVolume with CDI and lets
+// KubeVirt infer the instancetype and preference of the VM from the labels of the image.
+// The DataVolume requests 20Gi of storage from the default storage class, so clusters
+// running images verify need CDI and a default storage class with enough capacity.
+func WithInstancetypeInference() Option {
+	const storageSize = "20Gi"
+	return func(vm *v1.VirtualMachine) {
+		for i, volume := range vm.Spec.Template.Spec.Volumes {
+			if volume.Name != containerDiskName || volume.ContainerDisk == nil {
+				continue
+			}
+
+			vm.Spec.DataVolumeTemplates = append(vm.Spec.DataVolumeTemplates, v1.DataVolumeTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: vm.Name,
+				},
+				Spec: cdiv1.DataVolumeSpec{
+					Source: &cdiv1.DataVolumeSource{
+						Registry: &cdiv1.DataVolumeSourceRegistry{
+							URL: pointer.String("docker://" + volume.ContainerDisk.Image),
+						},
+					},
+					Storage: &cdiv1.StorageSpec{
+						Resources: k8sv1.ResourceRequirements{
+							Requests: k8sv1.ResourceList{
+								k8sv1.ResourceStorage: resource.MustParse(storageSize),
+							},
+						},
+					},
+				},
+			})
+			vm.Spec.Template.Spec.Volumes[i].VolumeSource = v1.VolumeSource{
+				DataVolume: &v1.DataVolumeSource{
+					Name: vm.Name,
+				},
+			}
+		}
+
+		// The instancetype provides the resources of the VM
+		vm.Spec.Template.Spec.Domain.Resources = v1.ResourceRequirements{}
+		vm.Spec.Instancetype = &v1.InstancetypeMatcher{
+			InferFromVolume: containerDiskName,
+		}
+		vm.Spec.Preference = &v1.PreferenceMatcher{
+			InferFromVolume: containerDiskName,
+		}
+	}
+}
+
 func Template() *template.Template {
 	caser := cases.Title(language.English)
 	funcMap := template.FuncMap{
@@ -179,6 +235,16 @@ func CloudInit(data *UserData) string {
 	return mustExecute(tpl, data)
 }
 
+// CloudInitFreeBSD renders cloud-init user data for FreeBSD guests, which have no sudo
+// by default and use the wheel group for administrative users.
+func CloudInitFreeBSD(data *UserData) string {
+	tpl := template.Must(
+		template.New("cloudinit-freebsd").Parse(cloudinitFreeBSDTemplate),
+	)
+
+	return mustExecute(tpl, data)
+}
+
 func Ignition(data *UserData) string {
 	funcMap := template.FuncMap{
 		"Quote": func(items []string) []string {
@@ -2,6 +2,7 @@ package hashsum
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 	"regexp"
 	"strings"
@@ -56,3 +57,48 @@ func Parse(stream io.Reader, format ChecksumFormat) (map[string]string, error) {
 	}
 	return checksums, nil
 }
+
+// ParseSingle parses checksum files which only contain the checksum of a single file,
+// like the per-file .sha256 files of openSUSE.
+func ParseSingle(stream io.Reader, format ChecksumFormat) (name string, checksum string, err error) {
+	checksums, err := Parse(stream, format)
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(checksums) != 1 {
+		return "", "", fmt.Errorf("expected exactly one checksum but found %d", len(checksums))
+	}
+
+	for name, checksum = range checksums {
+		break
+	}
+
+	return name, checksum, nil
+}
+
+// ParseDigests parses Gentoo style DIGESTS files, which contain one section per hash
+// algorithm, each introduced by a line like "# SHA512 HASH". Only the checksums of the
+// requested algorithm are returned.
+func ParseDigests(stream io.Reader, algorithm string) (map[string]string, error) {
+	header := fmt.Sprintf("# %s HASH", strings.ToUpper(algorithm))
+
+	section := strings.Builder{}
+	inSection := false
+	s := bufio.NewScanner(stream)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "#") {
+			inSection = line == header
+			continue
+		}
+		if inSection {
+			section.WriteString(line + "\n")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return Parse(strings.NewReader(section.String()), ChecksumFormatGNU)
+}
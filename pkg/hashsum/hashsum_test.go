@@ -96,6 +96,38 @@ var _ = Describe("Hashsum", func() {
 		Entry("CentOS-Stream Broken", "testdata/broken.checksum", ChecksumFormatBSD, checksumBrokenExpected),
 	)
 
+	It("ParseSingle should be able to parse single-entry checksum files", func() {
+		f, err := os.Open("testdata/single.checksum")
+		Expect(err).NotTo(HaveOccurred())
+		name, checksum, err := ParseSingle(f, ChecksumFormatGNU)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(name).To(Equal("openSUSE-Tumbleweed-Minimal-VM.x86_64-1.0.0-Cloud-Snapshot20230419.qcow2"))
+		Expect(checksum).To(Equal("6d7a1e4ba4fc5c0a1e0c0c3a6b0c2b7fa2f7e5f8a5a4c4d7e07dbc6bd1b2a0f3"))
+		err = f.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("ParseDigests should only return checksums of the requested algorithm", func() {
+		f, err := os.Open("testdata/digests.checksum")
+		Expect(err).NotTo(HaveOccurred())
+		got, err := ParseDigests(f, "sha256")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got).To(Equal(map[string]string{
+			"flatcar_production_qemu_image.img.bz2": "a0e3e2bd5dbee1a6b5a4a8b1bcd6c6ae4b0d4f83e8a1a3fa0c1e1bb1f7a6d2c9",
+		}))
+		err = f.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("ParseSingle should fail on checksum files with multiple entries", func() {
+		f, err := os.Open("testdata/gnu.checksum")
+		Expect(err).NotTo(HaveOccurred())
+		_, _, err = ParseSingle(f, ChecksumFormatGNU)
+		Expect(err).To(HaveOccurred())
+		err = f.Close()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
 })
 
 func TestHashsum(t *testing.T) {
@@ -0,0 +1,179 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrChecksumChanged is wrapped by the error returned when the upstream checksum
+// of an immutable reference changed without being accepted.
+var ErrChecksumChanged = errors.New("upstream checksum of an immutable reference changed")
+
+// Record is an upstream checksum and the time span it was observed in.
+type Record struct {
+	Checksum  string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// Timeline is the checksum history of a single name:tag reference.
+type Timeline struct {
+	// Immutable marks references whose upstream image must never change. It is
+	// set for references observed as immutable, but may also be set by hand.
+	Immutable bool `json:",omitempty"`
+	// Checksums contains the observed checksums, the current one comes last.
+	Checksums []Record
+}
+
+// Reference is a name:tag reference which is observed.
+type Reference struct {
+	Name      string
+	Immutable bool
+}
+
+// History tracks the upstream checksums of name:tag references. It is safe for concurrent use.
+type History struct {
+	lock     sync.Mutex
+	entries  map[string]*Timeline
+	accepted map[string]bool
+}
+
+func New() *History {
+	return &History{
+		entries:  map[string]*Timeline{},
+		accepted: map[string]bool{},
+	}
+}
+
+// Load reads the history from file. A missing file results in an empty history.
+func Load(file string) (*History, error) {
+	h := New()
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return nil, fmt.Errorf("error parsing checksum history %q: %v", file, err)
+	}
+
+	return h, nil
+}
+
+// Save writes the history to file.
+func (h *History) Save(file string) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	data, err := json.MarshalIndent(h.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	const permissionUserReadWrite = 0600
+	return os.WriteFile(file, data, permissionUserReadWrite)
+}
+
+// Accept allows the checksums of the given name:tag references to change once.
+func (h *History) Accept(names ...string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for _, name := range names {
+		h.accepted[name] = true
+	}
+}
+
+// Check returns an error wrapping ErrChecksumChanged if the checksum of an immutable
+// reference changed and the change was not accepted. Nothing is recorded.
+func (h *History) Check(checksum string, refs ...Reference) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return h.check(checksum, refs)
+}
+
+// Observe records checksum for all references. If the checksum of an immutable
+// reference changed and the change was not accepted, nothing is recorded and an
+// error wrapping ErrChecksumChanged is returned.
+func (h *History) Observe(checksum string, now time.Time, refs ...Reference) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if err := h.check(checksum, refs); err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		entry, exists := h.entries[ref.Name]
+		if !exists {
+			entry = &Timeline{}
+			h.entries[ref.Name] = entry
+		}
+		entry.Immutable = entry.Immutable || ref.Immutable
+
+		if current := entry.current(); current != nil && current.Checksum == checksum {
+			current.LastSeen = now
+			continue
+		}
+		entry.Checksums = append(entry.Checksums, Record{
+			Checksum:  checksum,
+			FirstSeen: now,
+			LastSeen:  now,
+		})
+	}
+
+	return nil
+}
+
+func (h *History) check(checksum string, refs []Reference) error {
+	var changed []string
+	for _, ref := range refs {
+		entry, exists := h.entries[ref.Name]
+		if !exists || (!entry.Immutable && !ref.Immutable) || h.accepted[ref.Name] {
+			continue
+		}
+		if current := entry.current(); current != nil && current.Checksum != checksum {
+			changed = append(changed, fmt.Sprintf("%s (%s -> %s)", ref.Name, current.Checksum, checksum))
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Errorf("%w: %v", ErrChecksumChanged, changed)
+	}
+
+	return nil
+}
+
+// Timeline returns a copy of the history of the reference name.
+func (h *History) Timeline(name string) (Timeline, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	entry, exists := h.entries[name]
+	if !exists {
+		return Timeline{}, false
+	}
+
+	return Timeline{
+		Immutable: entry.Immutable,
+		Checksums: append([]Record(nil), entry.Checksums...),
+	}, true
+}
+
+func (e *Timeline) current() *Record {
+	if len(e.Checksums) == 0 {
+		return nil
+	}
+
+	return &e.Checksums[len(e.Checksums)-1]
+}
@@ -0,0 +1,113 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("History", func() {
+	var (
+		h     *History
+		first time.Time
+		later time.Time
+	)
+
+	BeforeEach(func() {
+		h = New()
+		first = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+		later = first.Add(24 * time.Hour)
+	})
+
+	It("should record new checksums of mutable references", func() {
+		Expect(h.Observe("aaa", first, Reference{Name: "fedora:38"})).To(Succeed())
+		Expect(h.Observe("aaa", later, Reference{Name: "fedora:38"})).To(Succeed())
+		Expect(h.Observe("bbb", later, Reference{Name: "fedora:38"})).To(Succeed())
+
+		entry, exists := h.Timeline("fedora:38")
+		Expect(exists).To(BeTrue())
+		Expect(entry).To(Equal(Timeline{
+			Checksums: []Record{
+				{Checksum: "aaa", FirstSeen: first, LastSeen: later},
+				{Checksum: "bbb", FirstSeen: later, LastSeen: later},
+			},
+		}))
+	})
+
+	It("should reject changed checksums of immutable references", func() {
+		refs := []Reference{{Name: "fedora:38"}, {Name: "fedora:38-1.6", Immutable: true}}
+		Expect(h.Observe("aaa", first, refs...)).To(Succeed())
+
+		err := h.Observe("bbb", later, refs...)
+		Expect(errors.Is(err, ErrChecksumChanged)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("fedora:38-1.6 (aaa -> bbb)"))
+		Expect(err.Error()).ToNot(ContainSubstring("fedora:38 "))
+
+		entry, _ := h.Timeline("fedora:38")
+		Expect(entry.Checksums).To(HaveLen(1))
+	})
+
+	It("Check should reject changed checksums of immutable references without recording", func() {
+		ref := Reference{Name: "fedora:38-1.6", Immutable: true}
+		Expect(h.Check("aaa", ref)).To(Succeed())
+		_, exists := h.Timeline("fedora:38-1.6")
+		Expect(exists).To(BeFalse())
+
+		Expect(h.Observe("aaa", first, ref)).To(Succeed())
+		Expect(h.Check("bbb", ref)).To(MatchError(ErrChecksumChanged))
+		Expect(h.Check("aaa", ref)).To(Succeed())
+	})
+
+	It("should reject changed checksums of references marked immutable in the history", func() {
+		Expect(h.Observe("aaa", first, Reference{Name: "centos:7-2009"})).To(Succeed())
+		h.entries["centos:7-2009"].Immutable = true
+
+		Expect(h.Observe("bbb", later, Reference{Name: "centos:7-2009"})).To(MatchError(ErrChecksumChanged))
+	})
+
+	It("should record accepted checksum changes of immutable references", func() {
+		ref := Reference{Name: "centos:7-2009", Immutable: true}
+		Expect(h.Observe("aaa", first, ref)).To(Succeed())
+		h.Accept("centos:7-2009")
+		Expect(h.Observe("bbb", later, ref)).To(Succeed())
+
+		entry, _ := h.Timeline("centos:7-2009")
+		Expect(entry.Immutable).To(BeTrue())
+		Expect(entry.Checksums).To(HaveLen(2))
+		Expect(entry.Checksums[1].Checksum).To(Equal("bbb"))
+	})
+
+	It("should survive a roundtrip through a file", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "history.json")
+
+		loaded, err := Load(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.entries).To(BeEmpty())
+
+		Expect(h.Observe("aaa", first, Reference{Name: "centos:7-2009", Immutable: true})).To(Succeed())
+		Expect(h.Save(file)).To(Succeed())
+
+		loaded, err = Load(file)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loaded.entries).To(Equal(h.entries))
+		Expect(loaded.Observe("bbb", later, Reference{Name: "centos:7-2009"})).To(MatchError(ErrChecksumChanged))
+	})
+
+	It("should fail on a corrupt file", func() {
+		file := filepath.Join(GinkgoT().TempDir(), "history.json")
+		Expect(os.WriteFile(file, []byte("{"), 0600)).To(Succeed())
+
+		_, err := Load(file)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func TestHistory(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "History Suite")
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultFetchTimeout limits how long a download shared by CachedGetter may take.
+const DefaultFetchTimeout = 2 * time.Minute
+
+// CachedGetter caches the responses of GetAllWithContext by URL for the lifetime of the
+// getter. Concurrent requests for the same URL are deduplicated. The shared download is
+// detached from the contexts of the requests and limited by Timeout instead, so that one
+// canceled request does not fail the others. Every request still returns when its own
+// context is done. Returned content is shared and must not be modified.
+// Downloads with checksums are not cached, because they are used for the large images.
+type CachedGetter struct {
+	// Timeout limits how long a shared download may take.
+	Timeout time.Duration
+	getter  Getter
+	group   singleflight.Group
+	mu      sync.Mutex
+	cache   map[string][]byte
+}
+
+func (c *CachedGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error) {
+	c.mu.Lock()
+	content, exists := c.cache[fileURL]
+	c.mu.Unlock()
+	if exists {
+		return content, nil
+	}
+
+	resChan := c.group.DoChan(fileURL, func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+		defer cancel()
+
+		content, err := c.getter.GetAllWithContext(fetchCtx, fileURL)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.cache[fileURL] = content
+		c.mu.Unlock()
+
+		return content, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-resChan:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+func (c *CachedGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string, withSHA512 bool) (ReadCloserWithChecksum, error) {
+	return c.getter.GetWithChecksumAndContext(ctx, fileURL, withSHA512)
+}
+
+func NewCachedGetter(getter Getter) *CachedGetter {
+	return &CachedGetter{
+		Timeout: DefaultFetchTimeout,
+		getter:  getter,
+		cache:   map[string][]byte{},
+	}
+}
+
+// defaultHTTPGetter is the HTTPGetter behind DefaultGetter.
+var defaultHTTPGetter = &HTTPGetter{}
+
+// DefaultGetter is shared by all artifacts and gatherers, so that upstream metadata
+// like release indexes and checksum files is only downloaded once per run.
+var DefaultGetter Getter = NewCachedGetter(defaultHTTPGetter)
+
+// EnableDiskCache makes DefaultGetter keep downloaded metadata in dir and revalidate it
+// with ETag and Last-Modified across runs. It must be called before DefaultGetter is used.
+func EnableDiskCache(dir string) {
+	defaultHTTPGetter.CacheDir = dir
+}
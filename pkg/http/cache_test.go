@@ -0,0 +1,154 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		server        *httptest.Server
+		requests      atomic.Int32
+		revalidations atomic.Int32
+		release       chan struct{}
+	)
+
+	BeforeEach(func() {
+		requests.Store(0)
+		revalidations.Store(0)
+		release = make(chan struct{})
+		close(release)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			<-release
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidations.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte("releases"))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("CachedGetter should download every URL only once", func() {
+		getter := NewCachedGetter(&HTTPGetter{})
+		for i := 0; i < 3; i++ {
+			content, err := getter.GetAllWithContext(context.Background(), server.URL+"/releases.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("releases"))
+		}
+		Expect(requests.Load()).To(BeEquivalentTo(1))
+
+		_, err := getter.GetAllWithContext(context.Background(), server.URL+"/other.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests.Load()).To(BeEquivalentTo(2))
+	})
+
+	It("CachedGetter should deduplicate concurrent requests", func() {
+		release = make(chan struct{})
+		getter := NewCachedGetter(&HTTPGetter{})
+
+		const workers = 5
+		wg := &sync.WaitGroup{}
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				content, err := getter.GetAllWithContext(context.Background(), server.URL+"/releases.json")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("releases"))
+			}()
+		}
+		Eventually(requests.Load).Should(BeEquivalentTo(1))
+		close(release)
+		wg.Wait()
+
+		Expect(requests.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("CachedGetter should not fail waiting requests when the first request is canceled", func() {
+		release = make(chan struct{})
+		getter := NewCachedGetter(&HTTPGetter{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		firstErr := make(chan error, 1)
+		go func() {
+			_, err := getter.GetAllWithContext(ctx, server.URL+"/releases.json")
+			firstErr <- err
+		}()
+		Eventually(requests.Load).Should(BeEquivalentTo(1))
+
+		secondContent := make(chan []byte, 1)
+		go func() {
+			defer GinkgoRecover()
+			content, err := getter.GetAllWithContext(context.Background(), server.URL+"/releases.json")
+			Expect(err).NotTo(HaveOccurred())
+			secondContent <- content
+		}()
+
+		cancel()
+		Eventually(firstErr).Should(Receive(MatchError(context.Canceled)))
+		close(release)
+		Eventually(secondContent).Should(Receive(Equal([]byte("releases"))))
+		Expect(requests.Load()).To(BeEquivalentTo(1))
+	})
+
+	It("CachedGetter should limit the shared download by its timeout", func() {
+		release = make(chan struct{})
+		defer close(release)
+		getter := NewCachedGetter(&HTTPGetter{})
+		getter.Timeout = 100 * time.Millisecond
+
+		_, err := getter.GetAllWithContext(context.Background(), server.URL+"/releases.json")
+		Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+	})
+
+	It("HTTPGetter should revalidate cached content on disk", func() {
+		getter := &HTTPGetter{CacheDir: GinkgoT().TempDir()}
+		for i := 0; i < 2; i++ {
+			content, err := getter.GetAllWithContext(context.Background(), server.URL+"/releases.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("releases"))
+		}
+		Expect(requests.Load()).To(BeEquivalentTo(2))
+		Expect(revalidations.Load()).To(BeEquivalentTo(1))
+	})
+
+	DescribeTable("HTTPGetter should only calculate the SHA512 checksum if requested", func(withSHA512 bool, sha512Sum string) {
+		reader, err := (&HTTPGetter{}).GetWithChecksumAndContext(context.Background(), server.URL+"/releases.json", withSHA512)
+		Expect(err).NotTo(HaveOccurred())
+		defer reader.Close()
+		_, err = io.Copy(io.Discard, reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(reader.Checksum()).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("releases")))))
+		Expect(reader.SHA512Checksum()).To(Equal(sha512Sum))
+	},
+		Entry("with SHA512", true, fmt.Sprintf("%x", sha512.Sum512([]byte("releases")))),
+		Entry("without SHA512", false, ""),
+	)
+})
+
+func TestHTTP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HTTP Suite")
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const DefaultUserAgent = "medius (+https://github.com/kubevirt/containerdisks)"
+
+// ClientOptions configure the HTTP client which is shared by all network access of medius.
+type ClientOptions struct {
+	// ConnectTimeout limits how long establishing a connection may take.
+	ConnectTimeout time.Duration
+	// ReadTimeout limits how long a single read on a connection may block. It applies to
+	// waiting for response headers as well as to stalled downloads. Zero disables it.
+	ReadTimeout time.Duration
+	// UserAgent is sent with every request.
+	UserAgent string
+	// MaxConnsPerHost limits the number of concurrent connections per host. Zero means no limit.
+	MaxConnsPerHost int
+	// Proxy is the URL of the proxy to use. The proxy environment variables are used if it is empty.
+	Proxy string
+	// CAFiles are PEM files with root CAs which are trusted in addition to the system roots.
+	CAFiles []string
+	// ClientCertFile and ClientKeyFile are a PEM encoded client certificate and key for mTLS.
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// NewClient creates a client according to opts.
+func NewClient(opts *ClientOptions) (*http.Client, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := opts.ProxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	transport.TLSClientConfig = tlsConfig
+	transport.TLSHandshakeTimeout = opts.ConnectTimeout
+	transport.ResponseHeaderTimeout = opts.ReadTimeout
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	transport.DialContext = opts.DialContext()
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	return &http.Client{
+		Transport: &userAgentTransport{transport: transport, userAgent: userAgent},
+	}, nil
+}
+
+// ProxyFunc returns the proxy selection of opts for http.Transport.
+func (opts *ClientOptions) ProxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	if opts.Proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	proxyURL, err := url.Parse(opts.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the proxy URL: %v", err)
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
+
+// DialContext returns a dial function which applies the connect and read timeouts of opts.
+func (opts *ClientOptions) DialContext() func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil || opts.ReadTimeout == 0 {
+			return conn, err
+		}
+		return &readTimeoutConn{Conn: conn, timeout: opts.ReadTimeout}, nil
+	}
+}
+
+// ReadCAFiles returns the concatenated certificates of the CA files of opts.
+func (opts *ClientOptions) ReadCAFiles() ([]byte, error) {
+	var certs []byte
+	for _, caFile := range opts.CAFiles {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading the CA file: %v", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in the CA file %q", caFile)
+		}
+		certs = append(certs, pem...)
+		certs = append(certs, '\n')
+	}
+
+	return certs, nil
+}
+
+func newTLSConfig(opts *ClientOptions) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if len(opts.CAFiles) > 0 {
+		certs, err := opts.ReadCAFiles()
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pool.AppendCertsFromPEM(certs)
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading the client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// readTimeoutConn fails reads which block for longer than timeout.
+type readTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *readTimeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+
+	return c.Conn.Read(b)
+}
+
+type userAgentTransport struct {
+	transport http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+// defaultClient is shared by all network access of medius.
+var defaultClient = http.DefaultClient
+
+// DefaultClient returns the client which is shared by all network access of medius.
+func DefaultClient() *http.Client {
+	return defaultClient
+}
+
+// SetDefaultClient replaces the shared client. It must be called before any network access.
+func SetDefaultClient(client *http.Client) {
+	defaultClient = client
+	defaultHTTPGetter.Client = client
+}
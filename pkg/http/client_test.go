@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		userAgent string
+		handler   http.HandlerFunc
+	)
+
+	BeforeEach(func() {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.Header.Get("User-Agent")
+			_, _ = w.Write([]byte("releases"))
+		}
+	})
+
+	It("should send the configured user agent", func() {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		client, err := NewClient(&ClientOptions{UserAgent: "custom"})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = (&HTTPGetter{Client: client}).GetAllWithContext(context.Background(), server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userAgent).To(Equal("custom"))
+	})
+
+	It("should default the user agent", func() {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		client, err := NewClient(&ClientOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = (&HTTPGetter{Client: client}).GetAllWithContext(context.Background(), server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(userAgent).To(Equal(DefaultUserAgent))
+	})
+
+	It("should trust additional CAs", func() {
+		server := httptest.NewTLSServer(handler)
+		defer server.Close()
+
+		client, err := NewClient(&ClientOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = (&HTTPGetter{Client: client}).GetAllWithContext(context.Background(), server.URL)
+		Expect(err).To(HaveOccurred())
+
+		caFile := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		Expect(os.WriteFile(caFile, caPEM, 0o600)).To(Succeed())
+
+		client, err = NewClient(&ClientOptions{CAFiles: []string{caFile}})
+		Expect(err).NotTo(HaveOccurred())
+		content, err := (&HTTPGetter{Client: client}).GetAllWithContext(context.Background(), server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("releases"))
+	})
+
+	It("should send requests through the proxy", func() {
+		proxied := ""
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			proxied = r.URL.String()
+			_, _ = w.Write([]byte("proxied"))
+		}))
+		defer proxy.Close()
+
+		client, err := NewClient(&ClientOptions{Proxy: proxy.URL})
+		Expect(err).NotTo(HaveOccurred())
+		content, err := (&HTTPGetter{Client: client}).GetAllWithContext(context.Background(), "http://upstream.invalid/releases.json")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("proxied"))
+		Expect(proxied).To(Equal("http://upstream.invalid/releases.json"))
+	})
+
+	It("should fail stalled reads", func() {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		client, err := NewClient(&ClientOptions{ReadTimeout: 100 * time.Millisecond})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = (&HTTPGetter{Client: client}).GetAllWithContext(context.Background(), server.URL)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reject CA files without certificates", func() {
+		caFile := filepath.Join(GinkgoT().TempDir(), "ca.pem")
+		Expect(os.WriteFile(caFile, []byte("invalid"), 0o600)).To(Succeed())
+
+		_, err := NewClient(&ClientOptions{CAFiles: []string{caFile}})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// diskCacheEntry is the cached content of a URL together with its validators.
+type diskCacheEntry struct {
+	URL          string
+	ETag         string `json:",omitempty"`
+	LastModified string `json:",omitempty"`
+	Content      []byte
+}
+
+func (e *diskCacheEntry) setConditionalHeaders(req *http.Request) {
+	if e == nil {
+		return
+	}
+
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+func diskCachePath(dir, fileURL string) string {
+	sum := sha256.Sum256([]byte(fileURL))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readDiskCacheEntry returns nil if there is no usable cache entry for fileURL.
+func readDiskCacheEntry(dir, fileURL string) *diskCacheEntry {
+	raw, err := os.ReadFile(diskCachePath(dir, fileURL))
+	if err != nil {
+		return nil
+	}
+
+	entry := &diskCacheEntry{}
+	if err = json.Unmarshal(raw, entry); err != nil || entry.URL != fileURL {
+		return nil
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+
+	return entry
+}
+
+// writeDiskCacheEntry stores content if the response can be revalidated. The cache is
+// only an optimization, so failures to write it are ignored.
+func writeDiskCacheEntry(dir, fileURL string, header http.Header, content []byte) {
+	entry := &diskCacheEntry{
+		URL:          fileURL,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Content:      content,
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return
+	}
+
+	// Write to a temporary file first, so that concurrent runs never read partial entries
+	tmp, err := os.CreateTemp(dir, "entry-*.tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(raw)
+	if closeErr := tmp.Close(); err != nil || closeErr != nil {
+		return
+	}
+	_ = os.Rename(tmp.Name(), diskCachePath(dir, fileURL))
+}
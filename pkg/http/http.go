@@ -3,30 +3,40 @@ package http
 import (
 	"context"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"hash"
 	"io"
 	"net/http"
+	"time"
 )
 
+// Getter only offers context-aware methods, so that cancellation reaches every download.
 type Getter interface {
-	GetAll(fileURL string) ([]byte, error)
 	GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error)
-	GetWithChecksum(fileURL string) (ReadCloserWithChecksum, error)
-	GetWithChecksumAndContext(ctx context.Context, fileURL string) (ReadCloserWithChecksum, error)
+	// GetWithChecksumAndContext always calculates the SHA256 checksum of the downloaded file,
+	// the SHA512 checksum only if withSHA512 is true.
+	GetWithChecksumAndContext(ctx context.Context, fileURL string, withSHA512 bool) (ReadCloserWithChecksum, error)
 }
 
 type ReadCloserWithChecksum interface {
 	io.ReadCloser
+	// Checksum returns the SHA256 checksum of all data read so far.
 	Checksum() string
+	// SHA512Checksum returns the SHA512 checksum of all data read so far. It is empty
+	// if the SHA512 checksum was not requested.
+	SHA512Checksum() string
+	// LastModified returns the Last-Modified time of the file, it is zero if unknown.
+	LastModified() time.Time
 }
 
 type HTTPGetter struct {
-}
-
-func (h *HTTPGetter) GetAll(fileURL string) ([]byte, error) {
-	return h.GetAllWithContext(context.Background(), fileURL)
+	// Client is used for all requests, http.DefaultClient if nil.
+	Client *http.Client
+	// CacheDir enables an on-disk cache for GetAllWithContext if set. Cached content is
+	// revalidated with ETag and Last-Modified on every request.
+	CacheDir string
 }
 
 func (h *HTTPGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]byte, error) {
@@ -35,29 +45,43 @@ func (h *HTTPGetter) GetAllWithContext(ctx context.Context, fileURL string) ([]b
 		return nil, fmt.Errorf("failed to create request to load primary repository file from %s: %v", fileURL, err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	var cached *diskCacheEntry
+	if h.CacheDir != "" {
+		cached = readDiskCacheEntry(h.CacheDir, fileURL)
+		cached.setConditionalHeaders(req)
+	}
+
+	resp, err := h.client().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load primary repository file from %s: %v", fileURL, err)
 	}
 	defer resp.Body.Close()
 
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		return cached.Content, nil
+	}
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		return nil, fmt.Errorf("failed to download %s: %v ", fileURL, fmt.Errorf("status : %v", resp.StatusCode))
 	}
-	return io.ReadAll(resp.Body)
-}
 
-func (h *HTTPGetter) GetWithChecksum(fileURL string) (ReadCloserWithChecksum, error) {
-	return h.GetWithChecksumAndContext(context.Background(), fileURL)
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if h.CacheDir != "" {
+		writeDiskCacheEntry(h.CacheDir, fileURL, resp.Header, content)
+	}
+
+	return content, nil
 }
 
-func (h *HTTPGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string) (ReadCloserWithChecksum, error) {
+func (h *HTTPGetter) GetWithChecksumAndContext(ctx context.Context, fileURL string, withSHA512 bool) (ReadCloserWithChecksum, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, http.NoBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request to load primary repository file from %s: %v", fileURL, err)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := h.client().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load primary repository file from %s: %v", fileURL, err)
 	}
@@ -66,19 +90,40 @@ func (h *HTTPGetter) GetWithChecksumAndContext(ctx context.Context, fileURL stri
 		resp.Body.Close()
 		return nil, fmt.Errorf("failed to download %s: %v ", fileURL, fmt.Errorf("status : %v", resp.StatusCode))
 	}
-	return newReadCloserWithChecksum(resp.Body), nil
+	// A missing or malformed Last-Modified header leaves the time zero
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return newReadCloserWithChecksum(resp.Body, lastModified, withSHA512), nil
+}
+
+func (h *HTTPGetter) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+
+	return http.DefaultClient
 }
 
-func newReadCloserWithChecksum(body io.ReadCloser) *readCloserWithChecksum {
+func newReadCloserWithChecksum(body io.ReadCloser, lastModified time.Time, withSHA512 bool) *readCloserWithChecksum {
 	sha := sha256.New()
-	teeReader := io.TeeReader(body, sha)
-	return &readCloserWithChecksum{body: body, teeReader: teeReader, sha: sha}
+	var sha512Hash hash.Hash
+	var teeReader io.Reader
+	if withSHA512 {
+		sha512Hash = sha512.New()
+		teeReader = io.TeeReader(body, io.MultiWriter(sha, sha512Hash))
+	} else {
+		teeReader = io.TeeReader(body, sha)
+	}
+	return &readCloserWithChecksum{body: body, teeReader: teeReader, sha: sha, sha512: sha512Hash, lastModified: lastModified}
 }
 
 type readCloserWithChecksum struct {
 	body      io.ReadCloser
 	teeReader io.Reader
 	sha       hash.Hash
+	sha512    hash.Hash
+
+	lastModified time.Time
 }
 
 func (r *readCloserWithChecksum) Read(p []byte) (n int, err error) {
@@ -92,3 +137,14 @@ func (r *readCloserWithChecksum) Close() error {
 func (r *readCloserWithChecksum) Checksum() string {
 	return hex.EncodeToString(r.sha.Sum(nil))
 }
+
+func (r *readCloserWithChecksum) SHA512Checksum() string {
+	if r.sha512 == nil {
+		return ""
+	}
+	return hex.EncodeToString(r.sha512.Sum(nil))
+}
+
+func (r *readCloserWithChecksum) LastModified() time.Time {
+	return r.lastModified
+}
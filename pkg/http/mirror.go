@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MirrorURLs returns the locations to try for a download in order. Rewrites map a host
+// like "cloud-images.ubuntu.com" to a base URL like "https://mirror.example.com/ubuntu"
+// which replaces the scheme and host of every URL on that host. A rewritten URL is tried
+// before the URL it was derived from. Duplicates are removed.
+func MirrorURLs(urls []string, rewrites map[string]string) []string {
+	result := []string{}
+	seen := map[string]struct{}{}
+	add := func(u string) {
+		if _, exists := seen[u]; !exists {
+			seen[u] = struct{}{}
+			result = append(result, u)
+		}
+	}
+
+	for _, u := range urls {
+		if rewritten, ok := rewriteURL(u, rewrites); ok {
+			add(rewritten)
+		}
+		add(u)
+	}
+
+	return result
+}
+
+func rewriteURL(rawURL string, rewrites map[string]string) (string, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	base, exists := rewrites[parsed.Host]
+	if !exists {
+		return "", false
+	}
+
+	rewritten := strings.TrimSuffix(base, "/") + parsed.EscapedPath()
+	if parsed.RawQuery != "" {
+		rewritten += "?" + parsed.RawQuery
+	}
+
+	return rewritten, true
+}
@@ -0,0 +1,28 @@
+package http
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mirror", func() {
+	DescribeTable("MirrorURLs should order the download locations", func(urls []string, rewrites map[string]string, expected []string) {
+		Expect(MirrorURLs(urls, rewrites)).To(Equal(expected))
+	},
+		Entry("without rewrites",
+			[]string{"https://cloud.centos.org/image.qcow2", "https://mirror.example.com/image.qcow2"},
+			nil,
+			[]string{"https://cloud.centos.org/image.qcow2", "https://mirror.example.com/image.qcow2"},
+		),
+		Entry("with a rewrite",
+			[]string{"https://cloud-images.ubuntu.com/releases/22.04/image.img"},
+			map[string]string{"cloud-images.ubuntu.com": "https://mirror.internal/ubuntu/"},
+			[]string{"https://mirror.internal/ubuntu/releases/22.04/image.img", "https://cloud-images.ubuntu.com/releases/22.04/image.img"},
+		),
+		Entry("with a rewrite of a mirror and duplicates",
+			[]string{"https://cloud.centos.org/image.qcow2?arch=x86_64", "https://mirror.example.com/image.qcow2?arch=x86_64"},
+			map[string]string{"mirror.example.com": "https://cloud.centos.org"},
+			[]string{"https://cloud.centos.org/image.qcow2?arch=x86_64", "https://mirror.example.com/image.qcow2?arch=x86_64"},
+		),
+	)
+})
@@ -0,0 +1,158 @@
+package pgp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to verify signatures
+	"golang.org/x/crypto/openpgp"
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to verify signatures
+	"golang.org/x/crypto/openpgp/clearsign"
+
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+// Keyring contains the public keys which are trusted to sign upstream files.
+type Keyring struct {
+	Name     string
+	entities openpgp.EntityList
+}
+
+// NewKeyring parses ASCII armored public keys. Every key has to be pinned by the
+// fingerprint of its primary key, so that a changed key file does not silently
+// change what is trusted.
+func NewKeyring(name string, armored []byte, fingerprints ...string) (*Keyring, error) {
+	if len(fingerprints) == 0 {
+		return nil, fmt.Errorf("keyring %q has no pinned fingerprints", name)
+	}
+
+	entities, err := readArmoredKeys(armored)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keyring %q: %v", name, err)
+	}
+
+	pinned := map[string]bool{}
+	for _, fingerprint := range fingerprints {
+		pinned[normalizeFingerprint(fingerprint)] = false
+	}
+	for _, entity := range entities {
+		fingerprint := fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+		if _, exists := pinned[fingerprint]; !exists {
+			return nil, fmt.Errorf("keyring %q contains key %s which is not pinned", name, fingerprint)
+		}
+		pinned[fingerprint] = true
+	}
+	for fingerprint, found := range pinned {
+		if !found {
+			return nil, fmt.Errorf("keyring %q does not contain pinned key %s", name, fingerprint)
+		}
+	}
+
+	return &Keyring{Name: name, entities: entities}, nil
+}
+
+// MustNewKeyring is like NewKeyring but panics on errors. It is meant for keyrings
+// which are bundled with medius.
+func MustNewKeyring(name string, armored []byte, fingerprints ...string) *Keyring {
+	keyring, err := NewKeyring(name, armored, fingerprints...)
+	if err != nil {
+		panic(err)
+	}
+
+	return keyring
+}
+
+// VerifyClearsigned verifies a clearsigned message and returns only the signed
+// plaintext. Anything around the signed message, as well as the armor and the
+// dash-escaping, is stripped, so that unsigned content never reaches a parser.
+func (k *Keyring) VerifyClearsigned(data []byte) ([]byte, error) {
+	block, _ := clearsign.Decode(data)
+	if block == nil {
+		return nil, errors.New("no clearsigned message found")
+	}
+
+	if _, err := openpgp.CheckDetachedSignature(k.entities, bytes.NewReader(block.Bytes), block.ArmoredSignature.Body); err != nil {
+		return nil, fmt.Errorf("invalid signature for keyring %q: %v", k.Name, err)
+	}
+
+	return block.Plaintext, nil
+}
+
+// VerifyDetached verifies data with a detached signature, which can be ASCII armored or binary.
+func (k *Keyring) VerifyDetached(data, signature []byte) error {
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+
+	if _, err := check(k.entities, bytes.NewReader(data), bytes.NewReader(signature)); err != nil {
+		return fmt.Errorf("invalid signature for keyring %q: %v", k.Name, err)
+	}
+
+	return nil
+}
+
+// Signature describes how an upstream file is signed.
+type Signature struct {
+	Keyring *Keyring
+	// DetachedSuffix is appended to the URL of a file to get the URL of its detached
+	// signature, for example ".gpg". Files are expected to be clearsigned if it is empty.
+	DetachedSuffix string
+}
+
+// Fetch downloads the file at fileURL and returns its verified content. Content of
+// clearsigned files is returned without the armor. Fetching with a Signature without
+// a keyring fails, files are never returned unverified.
+func (s *Signature) Fetch(ctx context.Context, getter http.Getter, fileURL string) ([]byte, error) {
+	if s == nil || s.Keyring == nil {
+		return nil, fmt.Errorf("no keyring to verify %s", fileURL)
+	}
+
+	raw, err := getter.GetAllWithContext(ctx, fileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.DetachedSuffix == "" {
+		return s.Keyring.VerifyClearsigned(raw)
+	}
+
+	signature, err := getter.GetAllWithContext(ctx, fileURL+s.DetachedSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading the signature: %v", err)
+	}
+	if err = s.Keyring.VerifyDetached(raw, signature); err != nil {
+		return nil, err
+	}
+
+	return raw, nil
+}
+
+// readArmoredKeys reads all armored key blocks, since openpgp.ReadArmoredKeyRing
+// stops after the first one.
+func readArmoredKeys(armored []byte) (openpgp.EntityList, error) {
+	const header = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+	blocks := strings.Split(string(armored), header)[1:]
+	if len(blocks) == 0 {
+		return nil, errors.New("no armored public keys found")
+	}
+
+	entities := openpgp.EntityList{}
+	for _, block := range blocks {
+		blockEntities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(header + block))
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, blockEntities...)
+	}
+
+	return entities, nil
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToUpper(strings.ReplaceAll(fingerprint, " ", ""))
+}
@@ -0,0 +1,154 @@
+package pgp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/openpgp/clearsign"
+
+	"kubevirt.io/containerdisks/pkg/http"
+)
+
+const checksums = "SHA256 (image.qcow2) = 0b93b761fcaca720920095bdf606d3c4b87daf56ecd4e6213e5a51b90f4fe284\n"
+
+type staticGetter map[string][]byte
+
+func (s staticGetter) GetAllWithContext(_ context.Context, fileURL string) ([]byte, error) {
+	content, exists := s[fileURL]
+	if !exists {
+		return nil, fmt.Errorf("not found: %s", fileURL)
+	}
+	return content, nil
+}
+
+func (s staticGetter) GetWithChecksumAndContext(_ context.Context, _ string, _ bool) (http.ReadCloserWithChecksum, error) {
+	panic("implement me")
+}
+
+func newEntity() *openpgp.Entity {
+	entity, err := openpgp.NewEntity("signer", "", "signer@example.com", nil)
+	Expect(err).NotTo(HaveOccurred())
+	return entity
+}
+
+func armorPublicKey(entity *openpgp.Entity) []byte {
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(entity.Serialize(w)).To(Succeed())
+	Expect(w.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+func fingerprint(entity *openpgp.Entity) string {
+	return fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint)
+}
+
+func clearsignText(entity *openpgp.Entity, text string) []byte {
+	buf := &bytes.Buffer{}
+	w, err := clearsign.Encode(buf, entity.PrivateKey, nil)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = w.Write([]byte(text))
+	Expect(err).NotTo(HaveOccurred())
+	Expect(w.Close()).To(Succeed())
+	return buf.Bytes()
+}
+
+var _ = Describe("PGP", func() {
+	var (
+		signer  *openpgp.Entity
+		keyring *Keyring
+	)
+
+	BeforeEach(func() {
+		signer = newEntity()
+		keyring = MustNewKeyring("test", armorPublicKey(signer), fingerprint(signer))
+	})
+
+	It("NewKeyring should require every key to be pinned", func() {
+		other := newEntity()
+		armored := append(armorPublicKey(signer), armorPublicKey(other)...)
+
+		_, err := NewKeyring("test", armored, fingerprint(signer))
+		Expect(err).To(MatchError(ContainSubstring("not pinned")))
+		_, err = NewKeyring("test", armorPublicKey(signer), fingerprint(signer), fingerprint(other))
+		Expect(err).To(MatchError(ContainSubstring("does not contain pinned key")))
+		_, err = NewKeyring("test", armorPublicKey(signer))
+		Expect(err).To(MatchError(ContainSubstring("no pinned fingerprints")))
+		_, err = NewKeyring("test", armored, fingerprint(signer), fingerprint(other))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("VerifyClearsigned should only return the signed content", func() {
+		signed := clearsignText(signer, checksums+"- dash escaped line\n")
+		tampered := append([]byte("SHA256 (evil.qcow2) = 1234\n"), signed...)
+		tampered = append(tampered, []byte("SHA256 (evil.qcow2) = 5678\n")...)
+
+		content, err := keyring.VerifyClearsigned(tampered)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal(checksums + "- dash escaped line\n"))
+	})
+
+	It("VerifyClearsigned should reject invalid signatures", func() {
+		_, err := keyring.VerifyClearsigned([]byte(checksums))
+		Expect(err).To(MatchError(ContainSubstring("no clearsigned message found")))
+
+		_, err = keyring.VerifyClearsigned(clearsignText(newEntity(), checksums))
+		Expect(err).To(MatchError(ContainSubstring("invalid signature")))
+
+		modified := bytes.Replace(clearsignText(signer, checksums), []byte("0b93"), []byte("0b94"), 1)
+		_, err = keyring.VerifyClearsigned(modified)
+		Expect(err).To(MatchError(ContainSubstring("invalid signature")))
+	})
+
+	It("VerifyDetached should accept armored and binary signatures", func() {
+		armored := &bytes.Buffer{}
+		Expect(openpgp.ArmoredDetachSign(armored, signer, bytes.NewReader([]byte(checksums)), nil)).To(Succeed())
+		binary := &bytes.Buffer{}
+		Expect(openpgp.DetachSign(binary, signer, bytes.NewReader([]byte(checksums)), nil)).To(Succeed())
+
+		Expect(keyring.VerifyDetached([]byte(checksums), armored.Bytes())).To(Succeed())
+		Expect(keyring.VerifyDetached([]byte(checksums), binary.Bytes())).To(Succeed())
+		Expect(keyring.VerifyDetached([]byte(checksums+"\n"), binary.Bytes())).NotTo(Succeed())
+	})
+
+	It("Signature should fetch and verify files", func() {
+		binary := &bytes.Buffer{}
+		Expect(openpgp.DetachSign(binary, signer, bytes.NewReader([]byte(checksums)), nil)).To(Succeed())
+		getter := staticGetter{
+			"https://example.com/CHECKSUM":            clearsignText(signer, checksums),
+			"https://example.com/SHA256SUMS":          []byte(checksums),
+			"https://example.com/SHA256SUMS.gpg":      binary.Bytes(),
+			"https://example.com/unsigned/SHA256SUMS": []byte(checksums),
+		}
+
+		var unsigned *Signature
+		_, err := unsigned.Fetch(context.Background(), getter, "https://example.com/unsigned/SHA256SUMS")
+		Expect(err).To(MatchError(ContainSubstring("no keyring")))
+		_, err = (&Signature{}).Fetch(context.Background(), getter, "https://example.com/unsigned/SHA256SUMS")
+		Expect(err).To(MatchError(ContainSubstring("no keyring")))
+
+		content, err := (&Signature{Keyring: keyring}).Fetch(context.Background(), getter, "https://example.com/CHECKSUM")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal(checksums))
+
+		detached := &Signature{Keyring: keyring, DetachedSuffix: ".gpg"}
+		content, err = detached.Fetch(context.Background(), getter, "https://example.com/SHA256SUMS")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal(checksums))
+
+		_, err = detached.Fetch(context.Background(), getter, "https://example.com/unsigned/SHA256SUMS")
+		Expect(err).To(MatchError(ContainSubstring("error downloading the signature")))
+	})
+})
+
+func TestPGP(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PGP Suite")
+}
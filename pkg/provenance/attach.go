@@ -0,0 +1,261 @@
+package provenance
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// The provenance is attached to an image as an OCI artifact whose subject is the image
+// manifest. Registries which implement the referrers API index it when it is pushed.
+// Since pushing does not reveal if a registry implements the API, the referrers tag
+// schema of the distribution spec is always maintained as fallback as well: the tag
+// "sha256-<hex>" holds an index of all artifacts which refer to the image.
+const (
+	// MediaType is the artifact type of the provenance and the media type of the layer
+	// of all attached in-toto statements.
+	MediaType = "application/vnd.in-toto+json"
+
+	emptyConfigMediaType = "application/vnd.oci.empty.v1+json"
+)
+
+// ErrNotFound is returned by Fetch and FetchSBOM if no such artifact is attached to an image.
+var ErrNotFound = errors.New("no attestation found")
+
+var emptyConfig = []byte("{}")
+
+// descriptor is like v1.Descriptor but knows the artifactType, which the referrers
+// index needs to identify artifacts.
+type descriptor struct {
+	MediaType    types.MediaType   `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       v1.Hash           `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+type manifest struct {
+	SchemaVersion int64           `json:"schemaVersion"`
+	MediaType     types.MediaType `json:"mediaType"`
+	ArtifactType  string          `json:"artifactType"`
+	Config        descriptor      `json:"config"`
+	Layers        []descriptor    `json:"layers"`
+	Subject       *descriptor     `json:"subject,omitempty"`
+}
+
+type index struct {
+	SchemaVersion int64           `json:"schemaVersion"`
+	MediaType     types.MediaType `json:"mediaType"`
+	Manifests     []descriptor    `json:"manifests"`
+}
+
+// rawManifest lets remote.Put push manifests which go-containerregistry can't model.
+type rawManifest struct {
+	raw       []byte
+	mediaType types.MediaType
+}
+
+func (r *rawManifest) RawManifest() ([]byte, error) {
+	return r.raw, nil
+}
+
+func (r *rawManifest) MediaType() (types.MediaType, error) {
+	return r.mediaType, nil
+}
+
+// FallbackTag returns the tag of the referrers index of digest.
+func FallbackTag(repo name.Repository, digest v1.Hash) name.Tag {
+	return repo.Tag(fmt.Sprintf("%s-%s", digest.Algorithm, digest.Hex))
+}
+
+// Attach pushes statement as an artifact which refers to the image digest in repo.
+// A provenance which was attached to the image before is replaced in the referrers index.
+func Attach(ctx context.Context, repo name.Repository, digest v1.Hash, statement *Statement, options ...remote.Option) error {
+	return attach(ctx, repo, digest, MediaType, statement, options...)
+}
+
+// Fetch returns the provenance which is attached to the image digest in repo.
+func Fetch(ctx context.Context, repo name.Repository, digest v1.Hash, options ...remote.Option) (*Statement, error) {
+	statement := &Statement{}
+	if err := fetch(ctx, repo, digest, MediaType, statement, options...); err != nil {
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+// attach pushes the JSON encoding of content as an artifact of artifactType which refers
+// to the image digest in repo. Artifacts of the same type are replaced in the referrers index.
+func attach(ctx context.Context, repo name.Repository, digest v1.Hash, artifactType string, content interface{},
+	options ...remote.Option) error {
+	options = append(options, remote.WithContext(ctx))
+
+	subject, err := remote.Head(repo.Digest(digest.String()), options...)
+	if err != nil {
+		return fmt.Errorf("error reading the image %s@%s: %v", repo, digest, err)
+	}
+
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	config, err := writeBlob(repo, emptyConfig, emptyConfigMediaType, options...)
+	if err != nil {
+		return err
+	}
+	layer, err := writeBlob(repo, raw, MediaType, options...)
+	if err != nil {
+		return err
+	}
+
+	raw, err = json.Marshal(&manifest{
+		SchemaVersion: 2,
+		MediaType:     types.OCIManifestSchema1,
+		ArtifactType:  artifactType,
+		Config:        *config,
+		Layers:        []descriptor{*layer},
+		Subject: &descriptor{
+			MediaType: subject.MediaType,
+			Digest:    subject.Digest,
+			Size:      subject.Size,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	manifestDescriptor := descriptor{
+		MediaType:    types.OCIManifestSchema1,
+		ArtifactType: artifactType,
+		Digest:       sha256Hash(raw),
+		Size:         int64(len(raw)),
+	}
+	err = remote.Put(repo.Digest(manifestDescriptor.Digest.String()), &rawManifest{raw: raw, mediaType: types.OCIManifestSchema1}, options...)
+	if err != nil {
+		return fmt.Errorf("error pushing the %s artifact of %s@%s: %v", artifactType, repo, digest, err)
+	}
+
+	return updateFallbackIndex(repo, digest, &manifestDescriptor, options...)
+}
+
+// fetch decodes the latest artifact of artifactType which refers to the image digest in repo into content.
+func fetch(ctx context.Context, repo name.Repository, digest v1.Hash, artifactType string, content interface{},
+	options ...remote.Option) error {
+	options = append(options, remote.WithContext(ctx))
+
+	referrers, err := readFallbackIndex(repo, digest, options...)
+	if err != nil {
+		return err
+	}
+
+	for i := len(referrers.Manifests) - 1; i >= 0; i-- {
+		if referrers.Manifests[i].ArtifactType != artifactType {
+			continue
+		}
+		return fetchArtifact(repo, digest, &referrers.Manifests[i], content, options...)
+	}
+
+	return ErrNotFound
+}
+
+func fetchArtifact(repo name.Repository, digest v1.Hash, manifestDescriptor *descriptor, content interface{}, options ...remote.Option) error {
+	desc, err := remote.Get(repo.Digest(manifestDescriptor.Digest.String()), options...)
+	if err != nil {
+		return fmt.Errorf("error reading the %s manifest: %v", manifestDescriptor.ArtifactType, err)
+	}
+	m := &manifest{}
+	if err = json.Unmarshal(desc.Manifest, m); err != nil {
+		return fmt.Errorf("error parsing the %s manifest: %v", manifestDescriptor.ArtifactType, err)
+	}
+	if m.Subject == nil || m.Subject.Digest != digest || len(m.Layers) != 1 {
+		return fmt.Errorf("%s manifest %s does not refer to %s", manifestDescriptor.ArtifactType, manifestDescriptor.Digest, digest)
+	}
+
+	layer, err := remote.Layer(repo.Digest(m.Layers[0].Digest.String()), options...)
+	if err != nil {
+		return err
+	}
+	reader, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if err = json.NewDecoder(reader).Decode(content); err != nil {
+		return fmt.Errorf("error parsing the %s artifact: %v", manifestDescriptor.ArtifactType, err)
+	}
+
+	return nil
+}
+
+func updateFallbackIndex(repo name.Repository, digest v1.Hash, manifestDescriptor *descriptor, options ...remote.Option) error {
+	referrers, err := readFallbackIndex(repo, digest, options...)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	if referrers == nil {
+		referrers = &index{SchemaVersion: 2, MediaType: types.OCIImageIndex}
+	}
+
+	manifests := []descriptor{}
+	for _, referrer := range referrers.Manifests {
+		if referrer.ArtifactType != manifestDescriptor.ArtifactType {
+			manifests = append(manifests, referrer)
+		}
+	}
+	referrers.Manifests = append(manifests, *manifestDescriptor)
+
+	raw, err := json.Marshal(referrers)
+	if err != nil {
+		return err
+	}
+	if err = remote.Put(FallbackTag(repo, digest), &rawManifest{raw: raw, mediaType: types.OCIImageIndex}, options...); err != nil {
+		return fmt.Errorf("error pushing the referrers index of %s@%s: %v", repo, digest, err)
+	}
+
+	return nil
+}
+
+func readFallbackIndex(repo name.Repository, digest v1.Hash, options ...remote.Option) (*index, error) {
+	desc, err := remote.Get(FallbackTag(repo, digest), options...)
+	if err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error reading the referrers index of %s@%s: %v", repo, digest, err)
+	}
+
+	referrers := &index{}
+	if err = json.Unmarshal(desc.Manifest, referrers); err != nil {
+		return nil, fmt.Errorf("error parsing the referrers index of %s@%s: %v", repo, digest, err)
+	}
+
+	return referrers, nil
+}
+
+func writeBlob(repo name.Repository, content []byte, mediaType types.MediaType, options ...remote.Option) (*descriptor, error) {
+	if err := remote.WriteLayer(repo, static.NewLayer(content, mediaType), options...); err != nil {
+		return nil, fmt.Errorf("error pushing a blob to %s: %v", repo, err)
+	}
+
+	return &descriptor{
+		MediaType: mediaType,
+		Digest:    sha256Hash(content),
+		Size:      int64(len(content)),
+	}, nil
+}
+
+func sha256Hash(content []byte) v1.Hash {
+	sum := sha256.Sum256(content)
+	return v1.Hash{Algorithm: "sha256", Hex: fmt.Sprintf("%x", sum)}
+}
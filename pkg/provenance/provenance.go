@@ -0,0 +1,235 @@
+package provenance
+
+import (
+	"runtime/debug"
+	"strings"
+	"time"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+const (
+	StatementType   = "https://in-toto.io/Statement/v1"
+	PredicateType   = "https://slsa.dev/provenance/v1"
+	BuildType       = "https://github.com/kubevirt/containerdisks/medius/build/v1"
+	BuilderID       = "https://github.com/kubevirt/containerdisks/cmd/medius"
+	verifyByproduct = "verify"
+	testAnnotation  = "test/"
+)
+
+// Version and GitCommit identify the medius build. They can be set with
+// -ldflags "-X kubevirt.io/containerdisks/pkg/provenance.Version=...", otherwise
+// they are read from the build information of the binary.
+var (
+	Version   = ""
+	GitCommit = ""
+)
+
+// Statement is an in-toto statement with a SLSA provenance predicate.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+type Predicate struct {
+	BuildDefinition BuildDefinition `json:"buildDefinition"`
+	RunDetails      RunDetails      `json:"runDetails"`
+}
+
+type BuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   ExternalParameters   `json:"externalParameters"`
+	InternalParameters   InternalParameters   `json:"internalParameters"`
+	ResolvedDependencies []ResourceDescriptor `json:"resolvedDependencies"`
+}
+
+type ExternalParameters struct {
+	// Artifact is the containerdisk which was built, e.g. "fedora:38".
+	Artifact string `json:"artifact"`
+	// DownloadURL is the upstream location of the image.
+	DownloadURL string `json:"downloadURL"`
+	// ChecksumURL is the upstream file the checksum of the image was read from.
+	ChecksumURL string `json:"checksumURL,omitempty"`
+}
+
+type InternalParameters struct {
+	// MirrorURL is set if the image was downloaded from a mirror of DownloadURL.
+	MirrorURL   string `json:"mirrorURL,omitempty"`
+	Compression string `json:"compression,omitempty"`
+}
+
+type ResourceDescriptor struct {
+	Name        string            `json:"name,omitempty"`
+	URI         string            `json:"uri,omitempty"`
+	Digest      map[string]string `json:"digest,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type RunDetails struct {
+	Builder    Builder              `json:"builder"`
+	Metadata   BuildMetadata        `json:"metadata"`
+	Byproducts []ResourceDescriptor `json:"byproducts,omitempty"`
+}
+
+type Builder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+type BuildMetadata struct {
+	StartedOn  time.Time `json:"startedOn"`
+	FinishedOn time.Time `json:"finishedOn"`
+}
+
+// New creates the provenance of a containerdisk. The image digest is the subject, the
+// upstream image and the checksum file are the resolved dependencies. downloadedFrom is
+// the location the image was actually downloaded from.
+func New(subject string, digest v1.Hash, artifact string, details *api.ArtifactDetails, downloadedFrom string,
+	startedOn, finishedOn time.Time) *Statement {
+	dependencies := []ResourceDescriptor{{
+		Name:   "image",
+		URI:    details.DownloadURL,
+		Digest: checksums(details),
+	}}
+	if details.ChecksumURL != "" {
+		dependencies = append(dependencies, ResourceDescriptor{Name: "checksum", URI: details.ChecksumURL})
+	}
+
+	internal := InternalParameters{Compression: details.Compression}
+	if downloadedFrom != details.DownloadURL {
+		internal.MirrorURL = downloadedFrom
+	}
+
+	return &Statement{
+		Type: StatementType,
+		Subject: []Subject{{
+			Name:   subject,
+			Digest: map[string]string{digest.Algorithm: digest.Hex},
+		}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			BuildDefinition: BuildDefinition{
+				BuildType: BuildType,
+				ExternalParameters: ExternalParameters{
+					Artifact:    artifact,
+					DownloadURL: details.DownloadURL,
+					ChecksumURL: details.ChecksumURL,
+				},
+				InternalParameters:   internal,
+				ResolvedDependencies: dependencies,
+			},
+			RunDetails: RunDetails{
+				Builder: Builder{
+					ID:      BuilderID,
+					Version: builderVersion(),
+				},
+				Metadata: BuildMetadata{
+					StartedOn:  startedOn.UTC(),
+					FinishedOn: finishedOn.UTC(),
+				},
+			},
+		},
+	}
+}
+
+// SetVerification records the result of the verify stage and of its tests as a byproduct
+// of the build. An existing result is replaced.
+func (s *Statement) SetVerification(stage, result string, tests map[string]string) {
+	byproducts := []ResourceDescriptor{}
+	for _, byproduct := range s.Predicate.RunDetails.Byproducts {
+		if byproduct.Name != verifyByproduct {
+			byproducts = append(byproducts, byproduct)
+		}
+	}
+
+	annotations := map[string]string{
+		"stage":  stage,
+		"result": result,
+	}
+	for test, testResult := range tests {
+		annotations[testAnnotation+test] = testResult
+	}
+	s.Predicate.RunDetails.Byproducts = append(byproducts, ResourceDescriptor{
+		Name:        verifyByproduct,
+		Annotations: annotations,
+	})
+}
+
+// Verification returns the result of the verify stage and of its tests, if it was recorded.
+func (s *Statement) Verification() (result string, tests map[string]string, exists bool) {
+	for _, byproduct := range s.Predicate.RunDetails.Byproducts {
+		if byproduct.Name != verifyByproduct {
+			continue
+		}
+
+		tests = map[string]string{}
+		for key, value := range byproduct.Annotations {
+			if strings.HasPrefix(key, testAnnotation) {
+				tests[strings.TrimPrefix(key, testAnnotation)] = value
+			}
+		}
+		return byproduct.Annotations["result"], tests, true
+	}
+
+	return "", nil, false
+}
+
+// WithSubject returns a copy of the statement for the same digest in another repository.
+func (s *Statement) WithSubject(subject string) *Statement {
+	statement := *s
+	statement.Subject = make([]Subject, len(s.Subject))
+	for i := range s.Subject {
+		statement.Subject[i] = Subject{Name: subject, Digest: s.Subject[i].Digest}
+	}
+	statement.Predicate.RunDetails.Byproducts = append([]ResourceDescriptor{}, s.Predicate.RunDetails.Byproducts...)
+
+	return &statement
+}
+
+func checksums(details *api.ArtifactDetails) map[string]string {
+	digest := map[string]string{}
+	if details.SHA256Sum != "" {
+		digest["sha256"] = details.SHA256Sum
+	}
+	if details.SHA512Sum != "" {
+		digest["sha512"] = details.SHA512Sum
+	}
+
+	return digest
+}
+
+// Commit returns the git commit medius was built from, it is empty if unknown.
+func Commit() string {
+	return builderVersion()["commit"]
+}
+
+func builderVersion() map[string]string {
+	version := map[string]string{}
+	if Version != "" {
+		version["medius"] = Version
+	}
+	if GitCommit != "" {
+		version["commit"] = GitCommit
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if _, exists := version["medius"]; !exists && info.Main.Version != "" {
+			version["medius"] = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			if _, exists := version["commit"]; !exists && setting.Key == "vcs.revision" {
+				version["commit"] = setting.Value
+			}
+		}
+	}
+
+	return version
+}
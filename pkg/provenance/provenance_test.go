@@ -0,0 +1,234 @@
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+var _ = Describe("Provenance", func() {
+	var (
+		details   *api.ArtifactDetails
+		digest    v1.Hash
+		startedOn time.Time
+	)
+
+	BeforeEach(func() {
+		details = &api.ArtifactDetails{
+			SHA256Sum:   "3510fc7deb3e1939dbf3fe6f65a02ab1efcc763480bc352e4c06eca2e4f7c2a2",
+			ChecksumURL: "https://cloud.centos.org/centos/8/x86_64/images/CHECKSUM",
+			DownloadURL: "https://cloud.centos.org/centos/8/x86_64/images/CentOS-8-GenericCloud-8.4.2105-20210603.0.x86_64.qcow2",
+		}
+		digest = v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("a", 64)}
+		startedOn = time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	})
+
+	It("New should record the upstream image and checksum file", func() {
+		statement := New("quay.io/containerdisks/centos", digest, "centos:8.4", details, details.DownloadURL,
+			startedOn, startedOn.Add(time.Minute))
+
+		Expect(statement.Type).To(Equal(StatementType))
+		Expect(statement.PredicateType).To(Equal(PredicateType))
+		Expect(statement.Subject).To(Equal([]Subject{{
+			Name:   "quay.io/containerdisks/centos",
+			Digest: map[string]string{"sha256": digest.Hex},
+		}}))
+		Expect(statement.Predicate.BuildDefinition.ExternalParameters).To(Equal(ExternalParameters{
+			Artifact:    "centos:8.4",
+			DownloadURL: details.DownloadURL,
+			ChecksumURL: details.ChecksumURL,
+		}))
+		Expect(statement.Predicate.BuildDefinition.InternalParameters.MirrorURL).To(BeEmpty())
+		Expect(statement.Predicate.BuildDefinition.ResolvedDependencies).To(Equal([]ResourceDescriptor{
+			{Name: "image", URI: details.DownloadURL, Digest: map[string]string{"sha256": details.SHA256Sum}},
+			{Name: "checksum", URI: details.ChecksumURL},
+		}))
+		Expect(statement.Predicate.RunDetails.Builder.ID).To(Equal(BuilderID))
+		Expect(statement.Predicate.RunDetails.Metadata.FinishedOn).To(Equal(startedOn.Add(time.Minute)))
+	})
+
+	It("New should record mirrors", func() {
+		statement := New("quay.io/containerdisks/centos", digest, "centos:8.4", details, "https://mirror.example.com/image.qcow2",
+			startedOn, startedOn)
+		Expect(statement.Predicate.BuildDefinition.InternalParameters.MirrorURL).To(Equal("https://mirror.example.com/image.qcow2"))
+	})
+
+	It("SetVerification should replace earlier results", func() {
+		statement := New("quay.io/containerdisks/centos", digest, "centos:8.4", details, details.DownloadURL, startedOn, startedOn)
+		_, _, exists := statement.Verification()
+		Expect(exists).To(BeFalse())
+
+		statement.SetVerification("verify", "failed", map[string]string{"Boot": "passed", "SSH": "failed"})
+		statement.SetVerification("verify", "passed", map[string]string{"Boot": "passed"})
+		Expect(statement.Predicate.RunDetails.Byproducts).To(HaveLen(1))
+		result, tests, exists := statement.Verification()
+		Expect(exists).To(BeTrue())
+		Expect(result).To(Equal("passed"))
+		Expect(tests).To(Equal(map[string]string{"Boot": "passed"}))
+	})
+
+	It("NewSBOM should describe the upstream image", func() {
+		metadata := &api.Metadata{Name: "centos", Version: "8.4", URL: "https://www.centos.org/", Licenses: "LicenseRef-CentOS"}
+		sbom := NewSBOM("quay.io/containerdisks/centos", digest, metadata, details, startedOn)
+
+		Expect(sbom.Type).To(Equal(StatementType))
+		Expect(sbom.PredicateType).To(Equal(SBOMPredicateType))
+		Expect(sbom.Subject[0].Digest).To(Equal(map[string]string{"sha256": digest.Hex}))
+		Expect(sbom.Predicate.SPDXVersion).To(Equal("SPDX-2.3"))
+		Expect(sbom.Predicate.DocumentNamespace).To(HaveSuffix("/centos:8.4/sha256-" + digest.Hex))
+		Expect(sbom.Predicate.Packages).To(Equal([]SPDXPackage{{
+			SPDXID:           "SPDXRef-Package-image",
+			Name:             "centos",
+			VersionInfo:      "8.4",
+			DownloadLocation: details.DownloadURL,
+			Homepage:         "https://www.centos.org/",
+			Checksums:        []SPDXChecksum{{Algorithm: "SHA256", ChecksumValue: details.SHA256Sum}},
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "LicenseRef-CentOS",
+			CopyrightText:    "NOASSERTION",
+		}}))
+		Expect(sbom.Predicate.Relationships).To(Equal([]SPDXRelationship{{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: "SPDXRef-Package-image",
+		}}))
+	})
+
+	It("NewSBOM should not assert unknown licenses", func() {
+		sbom := NewSBOM("quay.io/containerdisks/centos", digest, &api.Metadata{Name: "centos", Version: "8.4"}, details, startedOn)
+		Expect(sbom.Predicate.Packages[0].LicenseDeclared).To(Equal("NOASSERTION"))
+	})
+
+	It("WithSubject should not modify the original statement", func() {
+		statement := New("registry.example.com/staging/centos", digest, "centos:8.4", details, details.DownloadURL, startedOn, startedOn)
+		promoted := statement.WithSubject("quay.io/containerdisks/centos")
+		promoted.SetVerification("verify", "passed", nil)
+
+		Expect(promoted.Subject[0].Name).To(Equal("quay.io/containerdisks/centos"))
+		Expect(promoted.Subject[0].Digest).To(Equal(statement.Subject[0].Digest))
+		Expect(statement.Subject[0].Name).To(Equal("registry.example.com/staging/centos"))
+		Expect(statement.Predicate.RunDetails.Byproducts).To(BeEmpty())
+	})
+
+	Context("with a registry", func() {
+		var (
+			server *httptest.Server
+			repo   name.Repository
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+
+			var err error
+			repo, err = name.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/containerdisks/centos")
+			Expect(err).NotTo(HaveOccurred())
+
+			img, err := random.Image(1024, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(remote.Write(repo.Tag("8.4"), img)).To(Succeed())
+			digest, err = img.Digest()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should attach and fetch the provenance", func() {
+			statement := New(repo.Name(), digest, "centos:8.4", details, details.DownloadURL, startedOn, startedOn)
+			Expect(Attach(context.Background(), repo, digest, statement)).To(Succeed())
+
+			fetched, err := Fetch(context.Background(), repo, digest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fetched).To(Equal(statement))
+		})
+
+		It("should refer to the image in the artifact manifest", func() {
+			statement := New(repo.Name(), digest, "centos:8.4", details, details.DownloadURL, startedOn, startedOn)
+			Expect(Attach(context.Background(), repo, digest, statement)).To(Succeed())
+
+			desc, err := remote.Get(FallbackTag(repo, digest))
+			Expect(err).NotTo(HaveOccurred())
+			referrers := &index{}
+			Expect(json.Unmarshal(desc.Manifest, referrers)).To(Succeed())
+			Expect(referrers.Manifests).To(HaveLen(1))
+			Expect(referrers.Manifests[0].ArtifactType).To(Equal(MediaType))
+
+			desc, err = remote.Get(repo.Digest(referrers.Manifests[0].Digest.String()))
+			Expect(err).NotTo(HaveOccurred())
+			m := &manifest{}
+			Expect(json.Unmarshal(desc.Manifest, m)).To(Succeed())
+			Expect(m.Subject).NotTo(BeNil())
+			Expect(m.Subject.Digest).To(Equal(digest))
+		})
+
+		It("should replace an earlier provenance", func() {
+			statement := New(repo.Name(), digest, "centos:8.4", details, details.DownloadURL, startedOn, startedOn)
+			Expect(Attach(context.Background(), repo, digest, statement)).To(Succeed())
+			statement.SetVerification("verify", "passed", nil)
+			Expect(Attach(context.Background(), repo, digest, statement)).To(Succeed())
+
+			fetched, err := Fetch(context.Background(), repo, digest)
+			Expect(err).NotTo(HaveOccurred())
+			result, _, exists := fetched.Verification()
+			Expect(exists).To(BeTrue())
+			Expect(result).To(Equal("passed"))
+
+			desc, err := remote.Get(FallbackTag(repo, digest))
+			Expect(err).NotTo(HaveOccurred())
+			referrers := &index{}
+			Expect(json.Unmarshal(desc.Manifest, referrers)).To(Succeed())
+			Expect(referrers.Manifests).To(HaveLen(1))
+		})
+
+		It("should keep the provenance and the SBOM side by side", func() {
+			statement := New(repo.Name(), digest, "centos:8.4", details, details.DownloadURL, startedOn, startedOn)
+			sbom := NewSBOM(repo.Name(), digest, &api.Metadata{Name: "centos", Version: "8.4"}, details, startedOn)
+			Expect(Attach(context.Background(), repo, digest, statement)).To(Succeed())
+			Expect(AttachSBOM(context.Background(), repo, digest, sbom)).To(Succeed())
+			Expect(AttachSBOM(context.Background(), repo, digest, sbom)).To(Succeed())
+
+			fetched, err := Fetch(context.Background(), repo, digest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fetched).To(Equal(statement))
+			fetchedSBOM, err := FetchSBOM(context.Background(), repo, digest)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fetchedSBOM).To(Equal(sbom))
+
+			desc, err := remote.Get(FallbackTag(repo, digest))
+			Expect(err).NotTo(HaveOccurred())
+			referrers := &index{}
+			Expect(json.Unmarshal(desc.Manifest, referrers)).To(Succeed())
+			Expect(referrers.Manifests).To(HaveLen(2))
+			Expect(referrers.Manifests[0].ArtifactType).To(Equal(MediaType))
+			Expect(referrers.Manifests[1].ArtifactType).To(Equal(SBOMArtifactType))
+		})
+
+		It("should return ErrNotFound for images without provenance", func() {
+			_, err := Fetch(context.Background(), repo, digest)
+			Expect(err).To(MatchError(ErrNotFound))
+			_, err = FetchSBOM(context.Background(), repo, digest)
+			Expect(err).To(MatchError(ErrNotFound))
+		})
+	})
+})
+
+func TestProvenance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Provenance Suite")
+}
@@ -0,0 +1,155 @@
+package provenance
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"kubevirt.io/containerdisks/pkg/api"
+)
+
+const (
+	// SBOMArtifactType is the artifact type of the SBOM, its layer is an in-toto statement.
+	SBOMArtifactType    = "application/spdx+json"
+	SBOMPredicateType   = "https://spdx.dev/Document"
+	spdxVersion         = "SPDX-2.3"
+	spdxDataLicense     = "CC0-1.0"
+	spdxNoAssertion     = "NOASSERTION"
+	spdxDocumentID      = "SPDXRef-DOCUMENT"
+	spdxPackageID       = "SPDXRef-Package-image"
+	spdxNamespacePrefix = "https://github.com/kubevirt/containerdisks/spdx/"
+	spdxCreator         = "Tool: medius"
+)
+
+// SBOMStatement is an in-toto statement with an SPDX document as predicate.
+type SBOMStatement struct {
+	Type          string       `json:"_type"`
+	Subject       []Subject    `json:"subject"`
+	PredicateType string       `json:"predicateType"`
+	Predicate     SPDXDocument `json:"predicate"`
+}
+
+// SPDXDocument is the subset of an SPDX 2.3 document medius knows about the content of
+// a containerdisk: the upstream image it was built from.
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  time.Time `json:"created"`
+	Creators []string  `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string         `json:"SPDXID"`
+	Name             string         `json:"name"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	Homepage         string         `json:"homepage,omitempty"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []SPDXChecksum `json:"checksums,omitempty"`
+	LicenseConcluded string         `json:"licenseConcluded"`
+	LicenseDeclared  string         `json:"licenseDeclared"`
+	CopyrightText    string         `json:"copyrightText"`
+}
+
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// NewSBOM creates the SBOM of a containerdisk. It describes the upstream image as the only
+// package, the content of the image is not analyzed.
+func NewSBOM(subject string, digest v1.Hash, metadata *api.Metadata, details *api.ArtifactDetails, created time.Time) *SBOMStatement {
+	licenses := metadata.Licenses
+	if licenses == "" {
+		licenses = spdxNoAssertion
+	}
+
+	var checksums []SPDXChecksum
+	if details.SHA256Sum != "" {
+		checksums = append(checksums, SPDXChecksum{Algorithm: "SHA256", ChecksumValue: details.SHA256Sum})
+	}
+	if details.SHA512Sum != "" {
+		checksums = append(checksums, SPDXChecksum{Algorithm: "SHA512", ChecksumValue: details.SHA512Sum})
+	}
+
+	return &SBOMStatement{
+		Type: StatementType,
+		Subject: []Subject{{
+			Name:   subject,
+			Digest: map[string]string{digest.Algorithm: digest.Hex},
+		}},
+		PredicateType: SBOMPredicateType,
+		Predicate: SPDXDocument{
+			SPDXVersion:       spdxVersion,
+			DataLicense:       spdxDataLicense,
+			SPDXID:            spdxDocumentID,
+			Name:              metadata.Describe(),
+			DocumentNamespace: fmt.Sprintf("%s%s/%s-%s", spdxNamespacePrefix, metadata.Describe(), digest.Algorithm, digest.Hex),
+			CreationInfo: SPDXCreationInfo{
+				Created:  created.UTC().Truncate(time.Second),
+				Creators: []string{spdxCreator},
+			},
+			Packages: []SPDXPackage{{
+				SPDXID:           spdxPackageID,
+				Name:             metadata.Name,
+				VersionInfo:      metadata.Version,
+				DownloadLocation: details.DownloadURL,
+				Homepage:         metadata.URL,
+				Checksums:        checksums,
+				LicenseConcluded: spdxNoAssertion,
+				LicenseDeclared:  licenses,
+				CopyrightText:    spdxNoAssertion,
+			}},
+			Relationships: []SPDXRelationship{{
+				SPDXElementID:      spdxDocumentID,
+				RelationshipType:   "DESCRIBES",
+				RelatedSPDXElement: spdxPackageID,
+			}},
+		},
+	}
+}
+
+// WithSubject returns a copy of the SBOM for the same digest in another repository.
+func (s *SBOMStatement) WithSubject(subject string) *SBOMStatement {
+	statement := *s
+	statement.Subject = make([]Subject, len(s.Subject))
+	for i := range s.Subject {
+		statement.Subject[i] = Subject{Name: subject, Digest: s.Subject[i].Digest}
+	}
+
+	return &statement
+}
+
+// AttachSBOM pushes sbom as an artifact which refers to the image digest in repo.
+// An SBOM which was attached to the image before is replaced in the referrers index.
+func AttachSBOM(ctx context.Context, repo name.Repository, digest v1.Hash, sbom *SBOMStatement, options ...remote.Option) error {
+	return attach(ctx, repo, digest, SBOMArtifactType, sbom, options...)
+}
+
+// FetchSBOM returns the SBOM which is attached to the image digest in repo.
+func FetchSBOM(ctx context.Context, repo name.Repository, digest v1.Hash, options ...remote.Option) (*SBOMStatement, error) {
+	sbom := &SBOMStatement{}
+	if err := fetch(ctx, repo, digest, SBOMArtifactType, sbom, options...); err != nil {
+		return nil, err
+	}
+
+	return sbom, nil
+}
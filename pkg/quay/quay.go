@@ -20,6 +20,7 @@ type QuayClient interface {
 type quayClient struct {
 	tokenFile string
 	org       string
+	client    *http.Client
 }
 
 func (q *quayClient) base(repository string) url.URL {
@@ -52,10 +53,9 @@ func (q *quayClient) json(ctx context.Context, method string, repo string, subre
 	}
 	repoURL := q.base(repo)
 	repoURL.Path = path.Join(repoURL.Path, subresource)
-	req, _ := http.NewRequest(method, repoURL.String(), bytes.NewBuffer(content))
+	req, _ := http.NewRequestWithContext(ctx, method, repoURL.String(), bytes.NewBuffer(content))
 	req.Header = header
-	cli := &http.Client{}
-	resp, err := cli.Do(req.WithContext(ctx))
+	resp, err := q.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("error performing rest call: %v", err)
 	}
@@ -78,8 +78,8 @@ func (q *quayClient) Update(ctx context.Context, repository string, description
 	return nil
 }
 
-func NewQuayClient(tokenFile string, org string) *quayClient {
-	return &quayClient{tokenFile: tokenFile, org: org}
+func NewQuayClient(tokenFile string, org string, client *http.Client) *quayClient {
+	return &quayClient{tokenFile: tokenFile, org: org, client: client}
 }
 
 type Description struct {
@@ -2,18 +2,19 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
-	"github.com/containers/image/v5/image"
-	"github.com/containers/image/v5/transports/alltransports"
-	"github.com/containers/image/v5/types"
-	"github.com/docker/distribution/registry/api/errcode"
-	v2 "github.com/docker/distribution/registry/api/v2"
+	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/pkg/errors"
+	"kubevirt.io/containerdisks/pkg/provenance"
+	"kubevirt.io/containerdisks/pkg/signing"
 )
 
 type ImageInfo struct {
@@ -28,65 +29,87 @@ type ImageInfo struct {
 }
 
 type Repository interface {
-	ImageMetadata(imgRef string, insecure bool) (*ImageInfo, error)
+	ImageMetadata(ctx context.Context, imgRef string, insecure bool) (*ImageInfo, error)
 	PushImage(ctx context.Context, img v1.Image, imgRef string) error
+	PullImage(ctx context.Context, imgRef string, insecure bool) (v1.Image, error)
 	CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error
+	ImageDigest(ctx context.Context, imgRef string, insecure bool) (v1.Hash, error)
+	ListTags(ctx context.Context, repo string, insecure bool) ([]string, error)
+	SignImage(ctx context.Context, signer *signing.Signer, imgRef string, digest v1.Hash, insecure bool) error
+	VerifyImage(ctx context.Context, verifier *signing.Verifier, imgRef string, digest v1.Hash, insecure bool) error
+	AttachProvenance(ctx context.Context, statement *provenance.Statement, imgRef string, digest v1.Hash, insecure bool) error
+	FetchProvenance(ctx context.Context, imgRef string, digest v1.Hash, insecure bool) (*provenance.Statement, error)
+	AttachSBOM(ctx context.Context, sbom *provenance.SBOMStatement, imgRef string, digest v1.Hash, insecure bool) error
+	FetchSBOM(ctx context.Context, imgRef string, digest v1.Hash, insecure bool) (*provenance.SBOMStatement, error)
 }
 
 type RepositoryImpl struct {
+	// Transport is used to push and copy images, the default transport of crane if nil.
+	Transport http.RoundTripper
+	// UserAgent is sent when reading image metadata.
+	UserAgent string
 }
 
-func (r RepositoryImpl) ImageMetadata(imgRef string, insecure bool) (imageInfo *ImageInfo, retErr error) {
-	sys := &types.SystemContext{
-		OCIInsecureSkipTLSVerify: insecure,
-	}
+// ImageMetadata reads the configuration of the image imgRef. Like all other registry access
+// it uses Transport, so that the proxy, CA and timeout settings of medius apply to it.
+func (r RepositoryImpl) ImageMetadata(ctx context.Context, imgRef string, insecure bool) (*ImageInfo, error) {
+	var options []name.Option
 	if insecure {
-		sys.DockerInsecureSkipTLSVerify = types.OptionalBoolTrue
+		options = append(options, name.Insecure)
 	}
-	ctx := context.Background()
-	src, err := parseImageSource(ctx, sys, fmt.Sprintf("docker://%s", imgRef))
+	ref, err := name.ParseReference(imgRef, options...)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error parsing image")
 	}
 
-	defer func() {
-		if closeErr := src.Close(); closeErr != nil {
-			retErr = errors.Wrap(closeErr, "could not close image")
-		}
-	}()
-
-	img, err := image.FromUnparsedImage(ctx, sys, image.UnparsedInstance(src, nil))
+	img, err := remote.Image(ref, append(r.remoteOptions(), remote.WithContext(ctx))...)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error parsing manifest for image")
 	}
-	imgInspect, err := img.Inspect(ctx)
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error inspecting image")
+	}
+	manifest, err := img.Manifest()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Error inspecting image")
 	}
-	imageInfo = &ImageInfo{
-		Tag: imgInspect.Tag,
-		// Digest is set below.
-		Created:       imgInspect.Created,
-		DockerVersion: imgInspect.DockerVersion,
-		Labels:        imgInspect.Labels,
-		Architecture:  imgInspect.Architecture,
-		Os:            imgInspect.Os,
-		Layers:        imgInspect.Layers,
-		Env:           imgInspect.Env,
+
+	imageInfo := &ImageInfo{
+		DockerVersion: config.DockerVersion,
+		Labels:        config.Config.Labels,
+		Architecture:  config.Architecture,
+		Os:            config.OS,
+		Layers:        []string{},
+		Env:           config.Config.Env,
+	}
+	if !config.Created.IsZero() {
+		created := config.Created.Time
+		imageInfo.Created = &created
+	}
+	for _, layer := range manifest.Layers {
+		imageInfo.Layers = append(imageInfo.Layers, layer.Digest.String())
 	}
 
-	return imageInfo, retErr
+	return imageInfo, nil
 }
 
 func (r RepositoryImpl) PushImage(ctx context.Context, img v1.Image, imgRef string) error {
-	return crane.Push(img, imgRef, crane.WithContext(ctx))
+	return crane.Push(img, imgRef, r.craneOptions(ctx)...)
 }
 
-func (r RepositoryImpl) CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error {
-	options := []crane.Option{
-		crane.WithContext(ctx),
+// PullImage returns the remote image imgRef, its layers are fetched lazily.
+func (r RepositoryImpl) PullImage(ctx context.Context, imgRef string, insecure bool) (v1.Image, error) {
+	options := r.craneOptions(ctx)
+	if insecure {
+		options = append(options, crane.Insecure)
 	}
 
+	return crane.Pull(imgRef, options...)
+}
+
+func (r RepositoryImpl) CopyImage(ctx context.Context, srcRef, dstRef string, insecure bool) error {
+	options := r.craneOptions(ctx)
 	if insecure {
 		options = append(options, crane.Insecure)
 	}
@@ -94,70 +117,166 @@ func (r RepositoryImpl) CopyImage(ctx context.Context, srcRef, dstRef string, in
 	return crane.Copy(srcRef, dstRef, options...)
 }
 
-func parseImageSource(ctx context.Context, sys *types.SystemContext, name string) (types.ImageSource, error) {
-	ref, err := alltransports.ParseImageName(name)
+func (r RepositoryImpl) ImageDigest(ctx context.Context, imgRef string, insecure bool) (v1.Hash, error) {
+	options := r.craneOptions(ctx)
+	if insecure {
+		options = append(options, crane.Insecure)
+	}
+
+	digest, err := crane.Digest(imgRef, options...)
+	if err != nil {
+		return v1.Hash{}, err
+	}
+
+	return v1.NewHash(digest)
+}
+
+// ListTags returns all tags of the repository repo, including signature and attestation tags.
+func (r RepositoryImpl) ListTags(ctx context.Context, repo string, insecure bool) ([]string, error) {
+	options := r.craneOptions(ctx)
+	if insecure {
+		options = append(options, crane.Insecure)
+	}
+
+	return crane.ListTags(repo, options...)
+}
+
+// SignImage stores a signature of digest in the repository of imgRef.
+func (r RepositoryImpl) SignImage(ctx context.Context, signer *signing.Signer, imgRef string, digest v1.Hash, insecure bool) error {
+	repo, err := parseRepository(imgRef, insecure)
+	if err != nil {
+		return err
+	}
+
+	return signer.Sign(ctx, repo, digest, r.remoteOptions()...)
+}
+
+// VerifyImage verifies that the repository of imgRef contains a valid signature of digest.
+func (r RepositoryImpl) VerifyImage(ctx context.Context, verifier *signing.Verifier, imgRef string, digest v1.Hash, insecure bool) error {
+	repo, err := parseRepository(imgRef, insecure)
+	if err != nil {
+		return err
+	}
+
+	return verifier.Verify(ctx, repo, digest, r.remoteOptions()...)
+}
+
+// AttachProvenance attaches statement to the image digest in the repository of imgRef.
+func (r RepositoryImpl) AttachProvenance(ctx context.Context, statement *provenance.Statement, imgRef string, digest v1.Hash,
+	insecure bool) error {
+	repo, err := parseRepository(imgRef, insecure)
+	if err != nil {
+		return err
+	}
+
+	return provenance.Attach(ctx, repo, digest, statement, r.remoteOptions()...)
+}
+
+// FetchProvenance returns the provenance of the image digest in the repository of imgRef.
+func (r RepositoryImpl) FetchProvenance(ctx context.Context, imgRef string, digest v1.Hash, insecure bool) (*provenance.Statement, error) {
+	repo, err := parseRepository(imgRef, insecure)
 	if err != nil {
 		return nil, err
 	}
 
-	return ref.NewImageSource(ctx, sys)
+	return provenance.Fetch(ctx, repo, digest, r.remoteOptions()...)
 }
 
-func IsManifestUnknownError(err error) bool {
-	ec := getErrorCode(err)
-	if ec == nil {
-		return false
+// AttachSBOM attaches sbom to the image digest in the repository of imgRef.
+func (r RepositoryImpl) AttachSBOM(ctx context.Context, sbom *provenance.SBOMStatement, imgRef string, digest v1.Hash,
+	insecure bool) error {
+	repo, err := parseRepository(imgRef, insecure)
+	if err != nil {
+		return err
 	}
 
-	switch ec.ErrorCode() {
-	case v2.ErrorCodeManifestUnknown:
-		return true
-	default:
-		return false
+	return provenance.AttachSBOM(ctx, repo, digest, sbom, r.remoteOptions()...)
+}
+
+// FetchSBOM returns the SBOM of the image digest in the repository of imgRef.
+func (r RepositoryImpl) FetchSBOM(ctx context.Context, imgRef string, digest v1.Hash, insecure bool) (*provenance.SBOMStatement, error) {
+	repo, err := parseRepository(imgRef, insecure)
+	if err != nil {
+		return nil, err
 	}
+
+	return provenance.FetchSBOM(ctx, repo, digest, r.remoteOptions()...)
 }
 
-func IsRepositoryUnknownError(err error) bool {
-	ec := getErrorCode(err)
-	if ec == nil {
-		return false
+func (r RepositoryImpl) craneOptions(ctx context.Context) []crane.Option {
+	options := []crane.Option{
+		crane.WithContext(ctx),
+	}
+	if r.Transport != nil {
+		options = append(options, crane.WithTransport(r.Transport))
+	}
+	if r.UserAgent != "" {
+		options = append(options, crane.WithUserAgent(r.UserAgent))
 	}
 
-	switch ec.ErrorCode() {
-	case v2.ErrorCodeNameUnknown:
-		return true
-	default:
-		return false
+	return options
+}
+
+func (r RepositoryImpl) remoteOptions() []remote.Option {
+	options := []remote.Option{
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+	if r.Transport != nil {
+		options = append(options, remote.WithTransport(r.Transport))
+	}
+	if r.UserAgent != "" {
+		options = append(options, remote.WithUserAgent(r.UserAgent))
 	}
+
+	return options
 }
 
-func IsTagUnknownError(err error) bool {
-	ec := getErrorCode(err)
-	if ec == nil {
-		return false
+func parseRepository(imgRef string, insecure bool) (name.Repository, error) {
+	var options []name.Option
+	if insecure {
+		options = append(options, name.Insecure)
 	}
 
-	if ec.ErrorCode().Error() == "unknown" {
-		// errors like this have no explicit error handling: "unknown: Tag 5.2 was deleted or has expired. To pull, revive via time machine"
-		if strings.Contains(err.Error(), "was deleted or has expired. To pull, revive via time machine") {
-			return true
-		}
+	ref, err := name.ParseReference(imgRef, options...)
+	if err != nil {
+		return name.Repository{}, err
 	}
-	return false
+
+	return ref.Context(), nil
 }
 
-func getErrorCode(err error) errcode.ErrorCoder {
-	for {
-		if unwrapped := errors.Unwrap(err); unwrapped != nil {
-			err = unwrapped
-		} else {
-			break
-		}
+func IsManifestUnknownError(err error) bool {
+	return hasErrorCode(err, transport.ManifestUnknownErrorCode)
+}
+
+func IsRepositoryUnknownError(err error) bool {
+	return hasErrorCode(err, transport.NameUnknownErrorCode)
+}
+
+func IsTagUnknownError(err error) bool {
+	// errors like this have no explicit error handling: "unknown: Tag 5.2 was deleted or has expired. To pull, revive via time machine"
+	return hasErrorCode(err, transport.UnknownErrorCode) &&
+		strings.Contains(err.Error(), "was deleted or has expired. To pull, revive via time machine")
+}
+
+// IsImageNotFoundError returns true if pulling an image failed because it does not exist.
+func IsImageNotFoundError(err error) bool {
+	var transportErr *transport.Error
+	return errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound
+}
+
+// hasErrorCode returns true if the registry responded with code.
+func hasErrorCode(err error, code transport.ErrorCode) bool {
+	var transportErr *transport.Error
+	if !errors.As(err, &transportErr) {
+		return false
 	}
 
-	if ec, ok := err.(errcode.ErrorCoder); ok {
-		return ec
+	for _, diagnostic := range transportErr.Errors {
+		if diagnostic.Code == code {
+			return true
+		}
 	}
 
-	return nil
+	return false
 }
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// countingTransport counts the requests which pass through it.
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("Repository", func() {
+	var (
+		server    *httptest.Server
+		host      string
+		transport *countingTransport
+		repo      RepositoryImpl
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+		host = strings.TrimPrefix(server.URL, "http://")
+		transport = &countingTransport{}
+		repo = RepositoryImpl{Transport: transport}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("ImageMetadata should read the labels through the configured transport", func() {
+		img, err := random.Image(1024, 1)
+		Expect(err).NotTo(HaveOccurred())
+		img, err = mutate.Config(img, v1.Config{Labels: map[string]string{"shasum": "abc"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crane.Push(img, host+"/containerdisks/fedora:38")).To(Succeed())
+
+		info, err := repo.ImageMetadata(context.Background(), host+"/containerdisks/fedora:38", true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Labels).To(HaveKeyWithValue("shasum", "abc"))
+		Expect(info.Layers).To(HaveLen(1))
+		Expect(transport.requests.Load()).To(BeNumerically(">", 0))
+	})
+
+	It("ImageMetadata should report unknown repositories and tags", func() {
+		_, err := repo.ImageMetadata(context.Background(), host+"/containerdisks/fedora:38", true)
+		Expect(IsRepositoryUnknownError(err)).To(BeTrue())
+
+		img, err := random.Image(1024, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crane.Push(img, host+"/containerdisks/fedora:38")).To(Succeed())
+		_, err = repo.ImageMetadata(context.Background(), host+"/containerdisks/fedora:39", true)
+		Expect(IsManifestUnknownError(err)).To(BeTrue())
+	})
+})
+
+func TestRepository(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Repository Suite")
+}
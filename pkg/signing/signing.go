@@ -0,0 +1,257 @@
+package signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Signatures are stored like cosign stores them, so that they can be verified with
+// "cosign verify --key". For every signed manifest digest an OCI image is pushed to
+// the tag "sha256-<hex>.sig" of the same repository. Each of its layers contains a
+// simple signing payload and the base64 encoded ECDSA signature of the payload in
+// the annotation SignatureAnnotation.
+const (
+	SimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	SignatureAnnotation    = "dev.cosignproject.cosign/signature"
+	signatureType          = "cosign container image signature"
+	signatureTagSuffix     = ".sig"
+)
+
+// Payload is the simple signing payload which is signed.
+type Payload struct {
+	Critical Critical               `json:"critical"`
+	Optional map[string]interface{} `json:"optional"`
+}
+
+type Critical struct {
+	Identity Identity `json:"identity"`
+	Image    Image    `json:"image"`
+	Type     string   `json:"type"`
+}
+
+type Identity struct {
+	DockerReference string `json:"docker-reference"`
+}
+
+type Image struct {
+	DockerManifestDigest string `json:"docker-manifest-digest"`
+}
+
+// Signer signs manifest digests with an ECDSA private key.
+type Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+// Verifier verifies signatures with an ECDSA public key.
+type Verifier struct {
+	key *ecdsa.PublicKey
+}
+
+// LoadSigner reads an unencrypted PEM encoded ECDSA private key in PKCS #8 or SEC 1 format.
+func LoadSigner(keyFile string) (*Signer, error) {
+	block, err := readPEM(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	if block.Type == "EC PRIVATE KEY" {
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the signing key: %v", err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the signing key is not an ECDSA key")
+	}
+
+	return &Signer{key: ecdsaKey}, nil
+}
+
+// LoadVerifier reads a PEM encoded ECDSA public key like the cosign.pub of cosign.
+func LoadVerifier(keyFile string) (*Verifier, error) {
+	block, err := readPEM(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the verification key: %v", err)
+	}
+
+	ecdsaKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("the verification key is not an ECDSA key")
+	}
+
+	return &Verifier{key: ecdsaKey}, nil
+}
+
+// SignatureTag returns the tag the signatures of digest are stored under.
+func SignatureTag(repo name.Repository, digest v1.Hash) name.Tag {
+	return repo.Tag(fmt.Sprintf("%s-%s%s", digest.Algorithm, digest.Hex, signatureTagSuffix))
+}
+
+// Sign pushes a signature of digest to repo. Existing signatures of digest are kept.
+func (s *Signer) Sign(ctx context.Context, repo name.Repository, digest v1.Hash, options ...remote.Option) error {
+	payload, err := json.Marshal(&Payload{
+		Critical: Critical{
+			Identity: Identity{DockerReference: repo.Name()},
+			Image:    Image{DockerManifestDigest: digest.String()},
+			Type:     signatureType,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, s.key, hash[:])
+	if err != nil {
+		return fmt.Errorf("error signing %s: %v", digest, err)
+	}
+
+	options = append(options, remote.WithContext(ctx))
+	tag := SignatureTag(repo, digest)
+	base, err := signatureImage(tag, options...)
+	if err != nil {
+		return err
+	}
+
+	img, err := mutate.Append(base, mutate.Addendum{
+		Layer: static.NewLayer(payload, SimpleSigningMediaType),
+		Annotations: map[string]string{
+			SignatureAnnotation: base64.StdEncoding.EncodeToString(signature),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err = remote.Write(tag, img, options...); err != nil {
+		return fmt.Errorf("error pushing the signature %s: %v", tag, err)
+	}
+
+	return nil
+}
+
+// Verify succeeds if repo contains at least one valid signature of digest.
+func (v *Verifier) Verify(ctx context.Context, repo name.Repository, digest v1.Hash, options ...remote.Option) error {
+	options = append(options, remote.WithContext(ctx))
+	tag := SignatureTag(repo, digest)
+	img, err := remote.Image(tag, options...)
+	if err != nil {
+		return fmt.Errorf("error reading the signatures %s: %v", tag, err)
+	}
+
+	manifest, err := img.Manifest()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error = errors.New("no signatures found")
+	for i := range manifest.Layers {
+		if lastErr = v.verifyLayer(img, &manifest.Layers[i], digest); lastErr == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no valid signature of %s found in %s: %v", digest, tag, lastErr)
+}
+
+func (v *Verifier) verifyLayer(img v1.Image, descriptor *v1.Descriptor, digest v1.Hash) error {
+	if descriptor.MediaType != SimpleSigningMediaType {
+		return fmt.Errorf("unexpected media type %q", descriptor.MediaType)
+	}
+	signature, err := base64.StdEncoding.DecodeString(descriptor.Annotations[SignatureAnnotation])
+	if err != nil {
+		return fmt.Errorf("error decoding the signature: %v", err)
+	}
+
+	layer, err := img.LayerByDigest(descriptor.Digest)
+	if err != nil {
+		return err
+	}
+	reader, err := layer.Compressed()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(v.key, hash[:], signature) {
+		return errors.New("invalid signature")
+	}
+
+	decoded := &Payload{}
+	if err = json.NewDecoder(bytes.NewReader(payload)).Decode(decoded); err != nil {
+		return fmt.Errorf("error parsing the signed payload: %v", err)
+	}
+	if decoded.Critical.Type != signatureType {
+		return fmt.Errorf("unexpected signature type %q", decoded.Critical.Type)
+	}
+	if decoded.Critical.Image.DockerManifestDigest != digest.String() {
+		return fmt.Errorf("signature is for %s", decoded.Critical.Image.DockerManifestDigest)
+	}
+
+	return nil
+}
+
+// signatureImage returns the existing signatures at tag or an empty OCI image.
+func signatureImage(tag name.Tag, options ...remote.Option) (v1.Image, error) {
+	img, err := remote.Image(tag, options...)
+	if err == nil {
+		return img, nil
+	}
+
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+		return mutate.ConfigMediaType(mutate.MediaType(empty.Image, types.OCIManifestSchema1), types.OCIConfigJSON), nil
+	}
+
+	return nil, fmt.Errorf("error reading the signatures %s: %v", tag, err)
+}
+
+func readPEM(keyFile string) (*pem.Block, error) {
+	raw, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the key: %v", err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil || !strings.HasSuffix(block.Type, "KEY") {
+		return nil, fmt.Errorf("no PEM encoded key found in %s", keyFile)
+	}
+
+	return block, nil
+}
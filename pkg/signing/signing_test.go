@@ -0,0 +1,151 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"kubevirt.io/containerdisks/testutil"
+)
+
+var _ = Describe("Signing", func() {
+	var (
+		server   *httptest.Server
+		repo     name.Repository
+		digest   v1.Hash
+		signer   *Signer
+		verifier *Verifier
+		other    *Verifier
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+
+		var err error
+		repo, err = name.NewRepository(strings.TrimPrefix(server.URL, "http://") + "/containerdisks/fedora")
+		Expect(err).NotTo(HaveOccurred())
+
+		img, err := random.Image(1024, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(remote.Write(repo.Tag("38"), img)).To(Succeed())
+		digest, err = img.Digest()
+		Expect(err).NotTo(HaveOccurred())
+
+		dir := GinkgoT().TempDir()
+		privateKeyFile, publicKeyFile, err := testutil.WriteKeyPair(dir, "medius")
+		Expect(err).NotTo(HaveOccurred())
+		_, otherPublicKeyFile, err := testutil.WriteKeyPair(dir, "other")
+		Expect(err).NotTo(HaveOccurred())
+		signer, err = LoadSigner(privateKeyFile)
+		Expect(err).NotTo(HaveOccurred())
+		verifier, err = LoadVerifier(publicKeyFile)
+		Expect(err).NotTo(HaveOccurred())
+		other, err = LoadVerifier(otherPublicKeyFile)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should store signatures in the cosign format", func() {
+		Expect(signer.Sign(context.Background(), repo, digest)).To(Succeed())
+
+		img, err := remote.Image(repo.Tag("sha256-" + digest.Hex + ".sig"))
+		Expect(err).NotTo(HaveOccurred())
+		manifest, err := img.Manifest()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Layers).To(HaveLen(1))
+		Expect(manifest.Layers[0].MediaType).To(BeEquivalentTo(SimpleSigningMediaType))
+		Expect(manifest.Layers[0].Annotations).To(HaveKey(SignatureAnnotation))
+	})
+
+	It("should verify signatures", func() {
+		Expect(signer.Sign(context.Background(), repo, digest)).To(Succeed())
+		Expect(verifier.Verify(context.Background(), repo, digest)).To(Succeed())
+	})
+
+	It("should keep existing signatures", func() {
+		Expect(signer.Sign(context.Background(), repo, digest)).To(Succeed())
+		Expect(signer.Sign(context.Background(), repo, digest)).To(Succeed())
+
+		img, err := remote.Image(SignatureTag(repo, digest))
+		Expect(err).NotTo(HaveOccurred())
+		layers, err := img.Layers()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(layers).To(HaveLen(2))
+	})
+
+	It("should reject unsigned images", func() {
+		Expect(verifier.Verify(context.Background(), repo, digest)).NotTo(Succeed())
+	})
+
+	It("should reject signatures of other keys", func() {
+		Expect(signer.Sign(context.Background(), repo, digest)).To(Succeed())
+		Expect(other.Verify(context.Background(), repo, digest)).To(MatchError(ContainSubstring("invalid signature")))
+	})
+
+	It("should reject signatures of other digests", func() {
+		otherDigest := v1.Hash{Algorithm: "sha256", Hex: strings.Repeat("0", 64)}
+		Expect(signer.Sign(context.Background(), repo, otherDigest)).To(Succeed())
+		Expect(remote.Tag(SignatureTag(repo, digest), mustImage(SignatureTag(repo, otherDigest)))).To(Succeed())
+
+		Expect(verifier.Verify(context.Background(), repo, digest)).To(MatchError(ContainSubstring("signature is for")))
+	})
+
+	It("should reject invalid keys", func() {
+		keyFile := filepath.Join(GinkgoT().TempDir(), "invalid.key")
+		Expect(os.WriteFile(keyFile, []byte("invalid"), 0o600)).To(Succeed())
+		_, err := LoadSigner(keyFile)
+		Expect(err).To(HaveOccurred())
+		_, err = LoadVerifier(keyFile)
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("should reject keys which are not ECDSA keys",
+		func(generateKey func() (crypto.Signer, error)) {
+			key, err := generateKey()
+			Expect(err).NotTo(HaveOccurred())
+			privateKeyFile, publicKeyFile, err := testutil.WriteKeys(key, GinkgoT().TempDir(), "medius")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = LoadSigner(privateKeyFile)
+			Expect(err).To(MatchError("the signing key is not an ECDSA key"))
+			_, err = LoadVerifier(publicKeyFile)
+			Expect(err).To(MatchError("the verification key is not an ECDSA key"))
+		},
+		Entry("RSA", func() (crypto.Signer, error) { return rsa.GenerateKey(rand.Reader, 2048) }),
+		Entry("Ed25519", func() (crypto.Signer, error) {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			return key, err
+		}),
+	)
+})
+
+func mustImage(tag name.Tag) v1.Image {
+	img, err := remote.Image(tag)
+	Expect(err).NotTo(HaveOccurred())
+	return img
+}
+
+func TestSigning(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Signing Suite")
+}
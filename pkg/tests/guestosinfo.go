@@ -15,7 +15,7 @@ func GuestOsInfo(ctx context.Context, vmi *v1.VirtualMachineInstance, _ *api.Art
 	}
 
 	return retryTest(ctx, func() error {
-		_, err := client.VirtualMachineInstance(vmi.Namespace).GuestOsInfo(vmi.Name)
+		_, err := client.VirtualMachineInstance(vmi.Namespace).GuestOsInfo(ctx, vmi.Name)
 		return err
 	})
 }
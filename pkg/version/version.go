@@ -0,0 +1,336 @@
+package version
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Comparator returns a negative number if a < b, zero if a == b and a positive number if a > b.
+type Comparator func(a, b string) int
+
+// SortDescending sorts versions in place from the newest to the oldest version.
+func SortDescending(versions []string, compare Comparator) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return compare(versions[i], versions[j]) > 0
+	})
+}
+
+// Newest returns the newest of versions or an empty string if there are none.
+func Newest(versions []string, compare Comparator) string {
+	newest := ""
+	for i, v := range versions {
+		if i == 0 || compare(v, newest) > 0 {
+			newest = v
+		}
+	}
+
+	return newest
+}
+
+// Major returns the leading component of a version, e.g. 39 for 39-1.5, 4 for 4.13
+// or 3 for v3.18.4. Epochs are dropped.
+func Major(v string) string {
+	_, v = splitEpoch(strings.TrimPrefix(v, "v"))
+	if idx := strings.IndexAny(v, ".-_~^+"); idx >= 0 {
+		return v[:idx]
+	}
+
+	return v
+}
+
+// CompareRPM compares versions like rpm does, e.g. 9-20231010.0 > 9-20231002.10,
+// 4.10 > 4.9 and 1.0 > 1.0~rc1. An optional epoch in the form 1:2.0 is respected.
+func CompareRPM(a, b string) int {
+	aEpoch, aRest := splitEpoch(a)
+	bEpoch, bRest := splitEpoch(b)
+	if res := compareNumeric(aEpoch, bEpoch); res != 0 {
+		return res
+	}
+
+	return rpmvercmp(aRest, bRest)
+}
+
+// CompareDebian compares versions like dpkg does. Versions are in the
+// [epoch:]upstream[-revision] format, a ~ sorts before everything, even the end of a version.
+func CompareDebian(a, b string) int {
+	aEpoch, aRest := splitEpoch(a)
+	bEpoch, bRest := splitEpoch(b)
+	if res := compareNumeric(aEpoch, bEpoch); res != 0 {
+		return res
+	}
+
+	aUpstream, aRevision := splitDebianRevision(aRest)
+	bUpstream, bRevision := splitDebianRevision(bRest)
+	if res := verrevcmp(aUpstream, bUpstream); res != 0 {
+		return res
+	}
+
+	return verrevcmp(aRevision, bRevision)
+}
+
+// CompareSemver compares semver-ish versions like v4.14.0-rc.1. A leading v and build
+// metadata are ignored, missing components count as zero and releases are newer than
+// their pre-releases.
+func CompareSemver(a, b string) int {
+	aCore, aPre := splitSemver(a)
+	bCore, bPre := splitSemver(b)
+	if res := compareIdentifiers(aCore, bCore, true); res != 0 {
+		return res
+	}
+
+	switch {
+	case aPre == nil && bPre == nil:
+		return 0
+	case aPre == nil:
+		return 1
+	case bPre == nil:
+		return -1
+	}
+
+	return compareIdentifiers(aPre, bPre, false)
+}
+
+func splitEpoch(v string) (epoch, rest string) {
+	if idx := strings.Index(v, ":"); idx > 0 {
+		if _, err := strconv.Atoi(v[:idx]); err == nil {
+			return v[:idx], v[idx+1:]
+		}
+	}
+
+	return "0", v
+}
+
+func splitDebianRevision(v string) (upstream, revision string) {
+	if idx := strings.LastIndex(v, "-"); idx >= 0 {
+		return v[:idx], v[idx+1:]
+	}
+
+	return v, ""
+}
+
+func splitSemver(v string) (core, pre []string) {
+	v = strings.TrimPrefix(v, "v")
+	if idx := strings.Index(v, "+"); idx >= 0 {
+		v = v[:idx]
+	}
+	if idx := strings.Index(v, "-"); idx >= 0 {
+		pre = strings.Split(v[idx+1:], ".")
+		v = v[:idx]
+	}
+
+	return strings.Split(v, "."), pre
+}
+
+// compareIdentifiers compares dot separated identifiers. Numeric identifiers are compared
+// numerically and sort before alphanumeric ones. If padZero is true, missing identifiers
+// count as zero, otherwise the longer list of identifiers is newer.
+func compareIdentifiers(a, b []string, padZero bool) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		if !padZero && (i >= len(a) || i >= len(b)) {
+			return len(a) - len(b)
+		}
+
+		aID, bID := "0", "0"
+		if i < len(a) {
+			aID = a[i]
+		}
+		if i < len(b) {
+			bID = b[i]
+		}
+
+		aNumeric, bNumeric := isNumeric(aID), isNumeric(bID)
+		var res int
+		switch {
+		case aNumeric && bNumeric:
+			res = compareNumeric(aID, bID)
+		case aNumeric:
+			res = -1
+		case bNumeric:
+			res = 1
+		default:
+			res = strings.Compare(aID, bID)
+		}
+		if res != 0 {
+			return res
+		}
+	}
+
+	return 0
+}
+
+// rpmvercmp implements the segment wise comparison of rpm.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	for len(a) > 0 || len(b) > 0 {
+		a = strings.TrimLeftFunc(a, isRPMSeparator)
+		b = strings.TrimLeftFunc(b, isRPMSeparator)
+
+		// A tilde sorts before everything else
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		// A caret sorts after the end of a version, but before everything else
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if a == "" {
+				return -1
+			}
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		numeric := isDigit(a[0])
+		var aSegment, bSegment string
+		aSegment, a = takeSegment(a, numeric)
+		bSegment, b = takeSegment(b, numeric)
+
+		// Numeric segments are newer than alphabetic ones
+		if bSegment == "" {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		var res int
+		if numeric {
+			res = compareNumeric(aSegment, bSegment)
+		} else {
+			res = strings.Compare(aSegment, bSegment)
+		}
+		if res != 0 {
+			return res
+		}
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+// verrevcmp implements the comparison of upstream versions and revisions of dpkg.
+func verrevcmp(a, b string) int {
+	for a != "" || b != "" {
+		for (a != "" && !isDigit(a[0])) || (b != "" && !isDigit(b[0])) {
+			aOrder, bOrder := debianOrder(a), debianOrder(b)
+			if aOrder != bOrder {
+				return aOrder - bOrder
+			}
+			a, b = a[1:], b[1:]
+		}
+
+		a = strings.TrimLeft(a, "0")
+		b = strings.TrimLeft(b, "0")
+		firstDiff := 0
+		for a != "" && b != "" && isDigit(a[0]) && isDigit(b[0]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[0]) - int(b[0])
+			}
+			a, b = a[1:], b[1:]
+		}
+		if a != "" && isDigit(a[0]) {
+			return 1
+		}
+		if b != "" && isDigit(b[0]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+
+	return 0
+}
+
+// debianOrder returns the sort weight of the first character of v.
+func debianOrder(v string) int {
+	const nonLetterOffset = 256
+
+	switch {
+	case v == "" || isDigit(v[0]):
+		return 0
+	case isLetter(v[0]):
+		return int(v[0])
+	case v[0] == '~':
+		return -1
+	default:
+		return int(v[0]) + nonLetterOffset
+	}
+}
+
+func takeSegment(v string, numeric bool) (segment, rest string) {
+	end := 0
+	for end < len(v) {
+		if numeric && !isDigit(v[end]) || !numeric && !isLetter(v[end]) {
+			break
+		}
+		end++
+	}
+
+	return v[:end], v[end:]
+}
+
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+
+	return strings.Compare(a, b)
+}
+
+func isNumeric(v string) bool {
+	if v == "" {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if !isDigit(v[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isRPMSeparator(r rune) bool {
+	isAlphanumeric := r < 128 && (isDigit(byte(r)) || isLetter(byte(r)))
+	return !isAlphanumeric && r != '~' && r != '^'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,101 @@
+package version
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	sign := func(res int) int {
+		switch {
+		case res < 0:
+			return -1
+		case res > 0:
+			return 1
+		default:
+			return 0
+		}
+	}
+
+	DescribeTable("CompareRPM", func(a, b string, expected int) {
+		Expect(sign(CompareRPM(a, b))).To(Equal(expected))
+		Expect(sign(CompareRPM(b, a))).To(Equal(-expected))
+	},
+		Entry("equal versions", "1.0", "1.0", 0),
+		Entry("numeric components", "4.10", "4.9", 1),
+		Entry("build dates", "9-20231010.0", "9-20231002.10", 1),
+		Entry("build serials", "9-20231002.10", "9-20231002.9", 1),
+		Entry("leading zeros", "1.010", "1.10", 0),
+		Entry("additional components", "1.0.1", "1.0", 1),
+		Entry("numeric is newer than alphabetic", "1.0.1", "1.0.a", 1),
+		Entry("tilde pre-releases", "1.0", "1.0~rc1", 1),
+		Entry("tilde pre-release order", "1.0~rc2", "1.0~rc1", 1),
+		Entry("caret snapshots", "1.0^20230101", "1.0", 1),
+		Entry("caret before additional components", "1.0.1", "1.0^20230101", 1),
+		Entry("separators are ignored", "1_0", "1.0", 0),
+		Entry("epochs", "1:1.0", "2.0", 1),
+		Entry("file names", "CentOS-8-GenericCloud-8.4.2105-20210603.0.x86_64.qcow2",
+			"CentOS-8-GenericCloud-8.3.2011-20201204.2.x86_64.qcow2", 1),
+	)
+
+	DescribeTable("CompareDebian", func(a, b string, expected int) {
+		Expect(sign(CompareDebian(a, b))).To(Equal(expected))
+		Expect(sign(CompareDebian(b, a))).To(Equal(-expected))
+	},
+		Entry("equal versions", "22.04", "22.04", 0),
+		Entry("ubuntu releases", "22.10", "22.04", 1),
+		Entry("ubuntu releases across years", "23.04", "22.10", 1),
+		Entry("revisions", "1.2-3", "1.2-2", 1),
+		Entry("numeric revisions", "1.2-10", "1.2-9", 1),
+		Entry("tilde sorts before the end", "1.0", "1.0~rc1", 1),
+		Entry("tilde sorts before everything", "1.0~rc1", "1.0~~", 1),
+		Entry("letters sort before non letters", "1.0+b1", "1.0a", 1),
+		Entry("epochs", "1:1.0", "2.0", 1),
+		Entry("leading zeros", "1.01", "1.1", 0),
+	)
+
+	DescribeTable("CompareSemver", func(a, b string, expected int) {
+		Expect(sign(CompareSemver(a, b))).To(Equal(expected))
+		Expect(sign(CompareSemver(b, a))).To(Equal(-expected))
+	},
+		Entry("equal versions", "1.2.3", "1.2.3", 0),
+		Entry("leading v", "v1.2.3", "1.2.3", 0),
+		Entry("missing components", "4.9", "4.9.0", 0),
+		Entry("minor versions", "4.10", "4.9", 1),
+		Entry("releases are newer than release candidates", "4.14.0", "4.14.0-rc.1", 1),
+		Entry("release candidates", "4.14.0-rc.10", "4.14.0-rc.9", 1),
+		Entry("alphanumeric identifiers are newer", "1.0.0-rc.1", "1.0.0-ec.2", 1),
+		Entry("numeric identifiers sort first", "1.0.0-alpha", "1.0.0-1", 1),
+		Entry("more identifiers are newer", "1.0.0-rc.1.1", "1.0.0-rc.1", 1),
+		Entry("build metadata is ignored", "1.0.0+build.2", "1.0.0+build.1", 0),
+	)
+
+	It("SortDescending should sort from the newest to the oldest version", func() {
+		versions := []string{"4.9", "4.12", "4.10", "4.13", "4.11"}
+		SortDescending(versions, CompareRPM)
+		Expect(versions).To(Equal([]string{"4.13", "4.12", "4.11", "4.10", "4.9"}))
+	})
+
+	It("Newest should return the newest version", func() {
+		Expect(Newest([]string{"9-20231002.10", "9-20231010.0", "9-20230925.0"}, CompareRPM)).To(Equal("9-20231010.0"))
+		Expect(Newest(nil, CompareRPM)).To(BeEmpty())
+	})
+
+	DescribeTable("Major", func(v, expected string) {
+		Expect(Major(v)).To(Equal(expected))
+	},
+		Entry("major only", "39", "39"),
+		Entry("dotted", "4.13", "4"),
+		Entry("dashed", "39-1.5", "39"),
+		Entry("leading v", "v3.18.4", "3"),
+		Entry("epoch", "1:2.0", "2"),
+		Entry("ubuntu", "22.04", "22"),
+	)
+})
+
+func TestVersion(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Version Suite")
+}
@@ -2,31 +2,56 @@ package testutil
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	"kubevirt.io/containerdisks/pkg/http"
 )
 
 type mockGetter struct {
-	mockFile string
+	mockFile  string
+	mockFiles map[string]string
 }
 
-func (m *mockGetter) GetAll(_ string) ([]byte, error) {
-	return os.ReadFile(m.mockFile)
-}
+func (m *mockGetter) GetAllWithContext(_ context.Context, fileURL string) ([]byte, error) {
+	if m.mockFiles != nil {
+		mockFile, ok := m.mockFiles[fileURL]
+		if !ok {
+			return nil, fmt.Errorf("no mock file for %s", fileURL)
+		}
+		return os.ReadFile(mockFile)
+	}
 
-func (m *mockGetter) GetAllWithContext(_ context.Context, _ string) ([]byte, error) {
 	return os.ReadFile(m.mockFile)
 }
 
-func (m *mockGetter) GetWithChecksum(_ string) (http.ReadCloserWithChecksum, error) {
-	panic("implement me")
-}
-
-func (m *mockGetter) GetWithChecksumAndContext(_ context.Context, _ string) (http.ReadCloserWithChecksum, error) {
+func (m *mockGetter) GetWithChecksumAndContext(_ context.Context, _ string, _ bool) (http.ReadCloserWithChecksum, error) {
 	panic("implement me")
 }
 
 func NewMockGetter(mockFile string) *mockGetter {
 	return &mockGetter{mockFile: mockFile}
 }
+
+// NewMockGetterWithFiles returns a mock getter which serves a different mock file per URL.
+func NewMockGetterWithFiles(mockFiles map[string]string) *mockGetter {
+	return &mockGetter{mockFiles: mockFiles}
+}
+
+type blockingGetter struct{}
+
+func (b *blockingGetter) GetAllWithContext(ctx context.Context, _ string) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (b *blockingGetter) GetWithChecksumAndContext(ctx context.Context, _ string, _ bool) (http.ReadCloserWithChecksum, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// NewBlockingGetter returns a getter which only returns the error of ctx once it is done,
+// like an upstream which never responds.
+func NewBlockingGetter() *blockingGetter {
+	return &blockingGetter{}
+}
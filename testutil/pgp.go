@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to sign test data
+	"golang.org/x/crypto/openpgp"
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to sign test data
+	"golang.org/x/crypto/openpgp/armor"
+	//nolint:staticcheck // x/crypto/openpgp is frozen, but sufficient to sign test data
+	"golang.org/x/crypto/openpgp/clearsign"
+
+	"kubevirt.io/containerdisks/pkg/pgp"
+)
+
+// NewSigner returns a freshly generated signing key and a keyring which trusts it.
+func NewSigner() (*openpgp.Entity, *pgp.Keyring, error) {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	armored := &bytes.Buffer{}
+	w, err := armor.Encode(armored, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = entity.Serialize(w); err != nil {
+		return nil, nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	keyring, err := pgp.NewKeyring("test", armored.Bytes(), fmt.Sprintf("%X", entity.PrimaryKey.Fingerprint))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entity, keyring, nil
+}
+
+// Clearsign returns data as a message clearsigned by entity.
+func Clearsign(entity *openpgp.Entity, data []byte) ([]byte, error) {
+	signed := &bytes.Buffer{}
+	w, err := clearsign.Encode(signed, entity.PrivateKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return signed.Bytes(), nil
+}
+
+// ClearsignFile writes file clearsigned by entity to dir and returns the path of the signed copy.
+func ClearsignFile(entity *openpgp.Entity, file, dir string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	signed, err := Clearsign(entity, data)
+	if err != nil {
+		return "", err
+	}
+
+	signedFile := filepath.Join(dir, filepath.Base(file))
+	if err = os.WriteFile(signedFile, signed, 0o600); err != nil {
+		return "", err
+	}
+
+	return signedFile, nil
+}
@@ -0,0 +1,46 @@
+package testutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+)
+
+// WriteKeyPair generates an ECDSA key pair for signing containerdisks and writes it to dir.
+func WriteKeyPair(dir, prefix string) (privateKeyFile, publicKeyFile string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return WriteKeys(key, dir, prefix)
+}
+
+// WriteKeys writes key as PEM encoded PKCS #8 private key and its public key as PEM encoded
+// PKIX public key to dir, like the cosign.key and cosign.pub files of cosign.
+func WriteKeys(key crypto.Signer, dir, prefix string) (privateKeyFile, publicKeyFile string, err error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", "", err
+	}
+
+	privateKeyFile = filepath.Join(dir, prefix+".key")
+	publicKeyFile = filepath.Join(dir, prefix+".pub")
+	if err = os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		return "", "", err
+	}
+	if err = os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		return "", "", err
+	}
+
+	return privateKeyFile, publicKeyFile, nil
+}